@@ -0,0 +1,229 @@
+package main
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// where the accounts get saved, relative to wherever the server is started
+const accountsFile = "accounts.json"
+
+const (
+	hashIterations = 100000
+	sessionLength  = 24 * time.Hour
+)
+
+var (
+	errBadLogin   = errors.New("wrong username or password")
+	errTakenName  = errors.New("that username is already taken")
+	errBadName    = errors.New("usernames are 3-16 letters, numbers or _")
+	errShortPass  = errors.New("passwords need at least 4 characters")
+	errBadSession = errors.New("session expired, please log in again")
+)
+
+// dummySalt is what Login hashes with for usernames that don't exist
+var dummySalt = make([]byte, 16)
+
+type Account struct {
+	Username string
+	Salt     []byte
+	Hash     []byte
+	Created  time.Time
+	Bot      bool `json:",omitempty"` // played by a program, not a person
+
+	Rating  float64
+	Wins    int
+	Losses  int
+	Draws   int
+	History []RatingChange
+}
+
+type session struct {
+	username string
+	expires  time.Time
+}
+
+// AccountStore keeps every registered account in memory and writes them out
+// to a json file whenever something changes. Sessions only live in memory,
+// restarting the server logs everybody out.
+type AccountStore struct {
+	mu       sync.Mutex
+	path     string
+	accounts map[string]*Account
+	sessions map[string]session
+}
+
+// LoadAccounts reads the accounts file. A missing file just means nobody has
+// registered yet.
+func LoadAccounts(path string) (*AccountStore, error) {
+	s := &AccountStore{
+		path:     path,
+		accounts: map[string]*Account{},
+		sessions: map[string]session{},
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*Account
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	for _, a := range list {
+		// accounts saved before ratings existed
+		if a.Rating == 0 {
+			a.Rating = startRating
+		}
+		s.accounts[a.Username] = a
+	}
+	return s, nil
+}
+
+// Register creates a new account and logs it in, returning a session token.
+// Bot accounts are for programs, they're marked as bots everywhere.
+func (s *AccountStore) Register(username, password string, bot bool) (string, error) {
+	if !validUsername(username) {
+		return "", errBadName
+	}
+	if len(password) < 4 {
+		return "", errShortPass
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	hash, err := hashPassword(password, salt)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accounts[username]; ok {
+		return "", errTakenName
+	}
+	s.accounts[username] = &Account{
+		Username: username,
+		Salt:     salt,
+		Hash:     hash,
+		Created:  time.Now(),
+		Rating:   startRating,
+		Bot:      bot,
+	}
+	if err := s.save(); err != nil {
+		delete(s.accounts, username)
+		return "", err
+	}
+	return s.newSession(username)
+}
+
+// Login checks a username and password and returns a session token.
+func (s *AccountStore) Login(username, password string) (string, error) {
+	s.mu.Lock()
+	a, ok := s.accounts[username]
+	s.mu.Unlock()
+
+	// a name nobody has is hashed all the same, otherwise how long the
+	// answer takes would say which names are taken
+	salt, want := dummySalt, []byte(nil)
+	if ok {
+		salt, want = a.Salt, a.Hash
+	}
+	hash, err := hashPassword(password, salt)
+	if err != nil {
+		return "", err
+	}
+	if !ok || subtle.ConstantTimeCompare(hash, want) != 1 {
+		return "", errBadLogin
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.newSession(username)
+}
+
+// Resume looks up the account behind a session token from an earlier login.
+func (s *AccountStore) Resume(token string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[token]
+	if !ok || time.Now().After(sess.expires) {
+		delete(s.sessions, token)
+		return "", errBadSession
+	}
+	return sess.username, nil
+}
+
+// IsBot says whether the account was registered as a bot
+func (s *AccountStore) IsBot(username string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.accounts[username]
+	return ok && a.Bot
+}
+
+// newSession must be called with s.mu held.
+func (s *AccountStore) newSession(username string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	s.sessions[token] = session{username: username, expires: time.Now().Add(sessionLength)}
+	return token, nil
+}
+
+// save must be called with s.mu held.
+func (s *AccountStore) save() error {
+	list := make([]*Account, 0, len(s.accounts))
+	for _, a := range s.accounts {
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Username < list[j].Username })
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to a temp file first so a crash can't leave half a file behind
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func hashPassword(password string, salt []byte) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, password, salt, hashIterations, 32)
+}
+
+func validUsername(name string) bool {
+	if len(name) < 3 || len(name) > 16 {
+		return false
+	}
+	for _, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestValidUsername(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"ann", true},
+		{"bob_2", true},
+		{"Zoë", true},
+		{"sixteen_letters_", true},
+		{"al", false},
+		{"seventeen_letters", false},
+		{"ann bob", false},
+		{"ann-bob", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := validUsername(tt.name); got != tt.ok {
+			t.Errorf("validUsername(%q) = %v, want %v", tt.name, got, tt.ok)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	s := testAccounts(t, "ann")
+	tests := []struct {
+		name, username, password string
+		want                     error
+	}{
+		{"bad name", "a b", "password", errBadName},
+		{"short password", "bob", "abc", errShortPass},
+		{"taken", "ann", "password", errTakenName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Register(tt.username, tt.password, false); err != tt.want {
+				t.Errorf("Register = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	token, err := s.Register("bot", "password", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, err := s.Resume(token); err != nil || name != "bot" {
+		t.Errorf("Resume = %q, %v", name, err)
+	}
+	if !s.IsBot("bot") || s.IsBot("ann") {
+		t.Error("bot isn't marked as a bot, or ann is")
+	}
+	if a := s.accounts["bot"]; a.Rating != startRating || string(a.Hash) == "password" {
+		t.Errorf("new account %+v", a)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	s := testAccounts(t, "ann")
+	tests := []struct {
+		name, username, password string
+		want                     error
+	}{
+		{"right", "ann", "password", nil},
+		{"wrong password", "ann", "Password", errBadLogin},
+		{"unknown user", "bob", "password", errBadLogin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := s.Login(tt.username, tt.password)
+			if err != tt.want {
+				t.Fatalf("Login = %v, want %v", err, tt.want)
+			}
+			if err != nil {
+				return
+			}
+			if name, err := s.Resume(token); err != nil || name != tt.username {
+				t.Errorf("Resume = %q, %v", name, err)
+			}
+		})
+	}
+}
+
+func TestResume(t *testing.T) {
+	s := testAccounts(t, "ann")
+	if _, err := s.Resume("nope"); err != errBadSession {
+		t.Errorf("unknown token: %v", err)
+	}
+
+	token, err := s.Login("ann", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess := s.sessions[token]
+	sess.expires = time.Now().Add(-time.Second)
+	s.sessions[token] = sess
+	if _, err := s.Resume(token); err != errBadSession {
+		t.Errorf("expired token: %v", err)
+	}
+	if _, ok := s.sessions[token]; ok {
+		t.Error("the expired session is still there")
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	s := testAccounts(t, "ann", "bob")
+	if err := s.RecordGame("ann", "bob", 1); err != nil {
+		t.Fatal(err)
+	}
+	token, err := s.Login("ann", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadAccounts(s.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"ann", "bob"} {
+		a, b := s.accounts[name], loaded.accounts[name]
+		if b == nil || b.Rating != a.Rating || b.Wins != a.Wins || b.Losses != a.Losses || len(b.History) != len(a.History) {
+			t.Errorf("%s saved as %+v, loaded as %+v", name, a, b)
+		}
+	}
+	if _, err := loaded.Login("ann", "password"); err != nil {
+		t.Errorf("can't log in after loading: %v", err)
+	}
+	// sessions aren't saved
+	if _, err := loaded.Resume(token); err != errBadSession {
+		t.Errorf("session survived a restart: %v", err)
+	}
+}
+
+// accounts saved before there were ratings start at the starting rating
+func TestLoadOldAccounts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), accountsFile)
+	old := `[{"Username":"ann","Salt":"AAAA","Hash":"AAAA","Created":"2024-01-01T00:00:00Z"}]`
+	if err := os.WriteFile(path, []byte(old), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadAccounts(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := s.accounts["ann"]; a == nil || a.Rating != startRating {
+		t.Errorf("loaded %+v", a)
+	}
+
+	if err := os.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadAccounts(path); err == nil {
+		t.Error("loaded a broken file")
+	}
+	// nobody has registered yet
+	if s, err := LoadAccounts(filepath.Join(t.TempDir(), accountsFile)); err != nil || len(s.accounts) != 0 {
+		t.Errorf("no file: %v", err)
+	}
+}
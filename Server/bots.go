@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"tictactoe/game"
+)
+
+// how long a bot gets for each move. People don't get a clock.
+const botMoveTime = 10 * time.Second
+
+// bots says which seats have a bot account in them
+func (r *Room) bots() []bool {
+	list := make([]bool, len(r.players))
+	found := false
+	for i, p := range r.players {
+		if p != nil && p.bot {
+			list[i] = true
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+	return list
+}
+
+// startClock starts the clock if it's a bot's turn and returns the time it
+// has in milliseconds, 0 for no clock. Every broadcast starts it again so a
+// move, a rematch or somebody sitting down all count.
+func (r *Room) startClock() int {
+	if r.clock != nil {
+		r.clock.Stop()
+		r.clock = nil
+	}
+	seat := r.state.ToMove()
+	if r.checkWin() != "" || seat < 1 || seat > len(r.players) {
+		return 0
+	}
+	bot := r.players[seat-1]
+	if bot == nil || !bot.bot {
+		return 0
+	}
+	turn := r.state.CurrentTurn()
+	r.clock = time.AfterFunc(botMoveTime, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		r.timeout(bot, turn)
+	})
+	return int(botMoveTime / time.Millisecond)
+}
+
+// timeout is a bot running out of time. It loses, in games for more than
+// two there's nobody in particular who wins so it's a draw.
+func (r *Room) timeout(bot *client, turn int) {
+	// a move or anything else since then would have stopped the clock, but
+	// it might have gone off just before
+	if rooms[r.ID] != r || r.checkWin() != "" || r.state.CurrentTurn() != turn || bot.room != r {
+		return
+	}
+	r.forfeit = "CAT"
+	if len(r.players) == 2 {
+		r.forfeit = fmt.Sprintf("Player %d", 3-bot.player)
+	}
+	r.post(game.Message{Text: bot.name + " ran out of time"})
+	r.finish()
+	r.broadcast()
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"tictactoe/game"
+)
+
+// chat limits
+const (
+	maxChatLength  = 200              // characters per message
+	chatBurst      = 5                // messages allowed inside chatWindow
+	chatWindow     = 10 * time.Second // ...before the sender has to slow down
+	repeatCooldown = 30 * time.Second // how long before the same message can be sent again
+	chatHistory    = 50               // messages a room remembers for people who join late
+)
+
+// words that get starred out. Matching is on the lowercase text so it also
+// catches them inside longer words.
+var bannedWords = []string{
+	"fuck",
+	"shit",
+	"bitch",
+	"bastard",
+	"asshole",
+	"cunt",
+	"dick",
+	"piss",
+}
+
+// say checks a chat message from c and sends it to everybody in the room
+func (r *Room) say(c *client, msg string) error {
+	msg = strings.TrimSpace(msg)
+	if msg == "" {
+		return nil
+	}
+	if utf8.RuneCountInString(msg) > maxChatLength {
+		return fmt.Errorf("messages can be at most %d characters", maxChatLength)
+	}
+	if err := c.throttle(msg); err != nil {
+		return err
+	}
+
+	r.post(game.Message{From: c.name, Text: censor(msg)})
+	return nil
+}
+
+// post adds a message to the room's history and sends it to everyone in the
+// room. Messages from the server itself have no From.
+func (r *Room) post(m game.Message) {
+	r.chat = append(r.chat, m)
+	if len(r.chat) > chatHistory {
+		r.chat = r.chat[len(r.chat)-chatHistory:]
+	}
+
+	for _, c := range r.everyone() {
+		if err := c.send(game.TypeChat, m); err != nil {
+			fmt.Println(err)
+		}
+	}
+}
+
+// throttle stops people flooding the chat. It allows chatBurst messages in
+// any chatWindow and doesn't let the same text be sent twice in a row
+// within repeatCooldown.
+func (c *client) throttle(msg string) error {
+	now := time.Now()
+
+	recent := c.chatTimes[:0]
+	for _, t := range c.chatTimes {
+		if now.Sub(t) < chatWindow {
+			recent = append(recent, t)
+		}
+	}
+	c.chatTimes = recent
+
+	if len(c.chatTimes) >= chatBurst {
+		return errors.New("you're sending messages too fast")
+	}
+	if strings.EqualFold(msg, c.lastChat) && now.Sub(c.lastChatTime) < repeatCooldown {
+		return errors.New("you just said that")
+	}
+
+	c.chatTimes = append(c.chatTimes, now)
+	c.lastChat = msg
+	c.lastChatTime = now
+	return nil
+}
+
+// censor replaces every banned word with stars, keeping the rest of the
+// message as it was typed. It goes a letter at a time since lowercasing
+// can change how many bytes a letter takes.
+func censor(msg string) string {
+	out := []rune(msg)
+	lower := make([]rune, len(out))
+	for i, r := range out {
+		lower[i] = unicode.ToLower(r)
+	}
+	for _, word := range bannedWords {
+		w := []rune(word)
+		for i := 0; i+len(w) <= len(lower); {
+			if !slices.Equal(lower[i:i+len(w)], w) {
+				i++
+				continue
+			}
+			for k := i; k < i+len(w); k++ {
+				out[k] = '*'
+			}
+			i += len(w)
+		}
+	}
+	return string(out)
+}
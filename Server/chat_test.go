@@ -0,0 +1,152 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"tictactoe/game"
+)
+
+// testClient is a client without a connection, nothing writes out what
+// gets sent to it so sent can read it back
+type testClient struct {
+	*client
+}
+
+func newTestClient(name string) testClient {
+	return testClient{&client{name: name, out: make(chan game.Envelope, sendQueue)}}
+}
+
+// sent is every message of type typ the client got since the last call
+func (c testClient) sent(t *testing.T, typ string) []game.Envelope {
+	t.Helper()
+	var list []game.Envelope
+	for {
+		select {
+		case env := <-c.out:
+			if env.Type == typ {
+				list = append(list, env)
+			}
+		default:
+			return list
+		}
+	}
+}
+
+func TestCensor(t *testing.T) {
+	tests := []struct {
+		msg, want string
+	}{
+		{"good game", "good game"},
+		{"oh shit", "oh ****"},
+		{"SHIT happens", "**** happens"},
+		{"shitshit", "********"},
+		{"a dickens novel", "a ****ens novel"},
+		{"İstanbul shit", "İstanbul ****"}, // lowercase is a different length
+		{"SHİT dic\u212a", "**** ****"},    // İ is an i, the kelvin sign a k
+		{"shit é shit", "**** é ****"},
+	}
+	for _, tt := range tests {
+		if got := censor(tt.msg); got != tt.want {
+			t.Errorf("censor(%q) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	c := &client{}
+	for i := 0; i < chatBurst; i++ {
+		if err := c.throttle(strings.Repeat("a", i+1)); err != nil {
+			t.Fatalf("message %d: %v", i+1, err)
+		}
+	}
+	if err := c.throttle("one too many"); err == nil {
+		t.Error("got past the burst")
+	}
+
+	// a while later there's room again
+	for i := range c.chatTimes {
+		c.chatTimes[i] = c.chatTimes[i].Add(-chatWindow)
+	}
+	if err := c.throttle("back again"); err != nil {
+		t.Errorf("still throttled after the window: %v", err)
+	}
+}
+
+func TestThrottleRepeats(t *testing.T) {
+	c := &client{}
+	if err := c.throttle("gg"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.throttle("GG"); err == nil {
+		t.Error("the same message went through twice")
+	}
+	if err := c.throttle("wp"); err != nil {
+		t.Errorf("a different message: %v", err)
+	}
+	if err := c.throttle("wp"); err == nil {
+		t.Error("the same message went through twice")
+	}
+	c.lastChatTime = c.lastChatTime.Add(-repeatCooldown - time.Second)
+	if err := c.throttle("wp"); err != nil {
+		t.Errorf("a repeat after the cooldown: %v", err)
+	}
+}
+
+func TestSay(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		ok   bool
+		want string // what everybody sees, "" for nothing
+	}{
+		{"plain", "hello", true, "hello"},
+		{"trimmed", "  hi  ", true, "hi"},
+		{"censored", "piss off", true, "**** off"},
+		{"empty", "   ", true, ""},
+		{"longest", strings.Repeat("é", maxChatLength), true, strings.Repeat("é", maxChatLength)},
+		{"too long", strings.Repeat("a", maxChatLength+1), false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ann, bob := newTestClient("ann"), newTestClient("bob")
+			r := &Room{players: []*client{ann.client, nil}, spectators: map[*client]bool{bob.client: true}}
+			err := r.say(ann.client, tt.msg)
+			if (err == nil) != tt.ok {
+				t.Fatalf("say = %v, want ok %v", err, tt.ok)
+			}
+			for _, c := range []testClient{ann, bob} {
+				got := c.sent(t, game.TypeChat)
+				if tt.want == "" {
+					if len(got) != 0 {
+						t.Errorf("%s got %d messages", c.name, len(got))
+					}
+					continue
+				}
+				var m game.Message
+				if len(got) != 1 || got[0].Open(&m) != nil || m.From != "ann" || m.Text != tt.want {
+					t.Errorf("%s got %v, want %q from ann", c.name, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// a room only keeps the last chatHistory messages for people who come in
+// late
+func TestChatHistory(t *testing.T) {
+	r := &Room{spectators: map[*client]bool{}}
+	for i := 0; i < chatHistory+10; i++ {
+		r.post(game.Message{Text: strings.Repeat("x", i+1)})
+	}
+	if len(r.chat) != chatHistory || len(r.chat[0].Text) != 11 {
+		t.Errorf("%d messages starting with %q", len(r.chat), r.chat[0].Text)
+	}
+
+	late := newTestClient("late")
+	r.sendHistory(late.client)
+	if got := late.sent(t, game.TypeChat); len(got) != chatHistory {
+		t.Errorf("caught up on %d messages, want %d", len(got), chatHistory)
+	}
+}
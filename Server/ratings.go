@@ -0,0 +1,135 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"tictactoe/game"
+)
+
+// Elo settings. Everybody starts at 1200 and a single game can move a rating
+// by at most kFactor points.
+const (
+	startRating = 1200
+	kFactor     = 32
+)
+
+// how many players the leaderboard shows
+const leaderboardSize = 10
+
+// RatingChange is one entry in an account's rating history
+type RatingChange struct {
+	Time     time.Time
+	Opponent string
+	Result   string // "win", "loss" or "draw"
+	Before   float64
+	After    float64
+}
+
+// expectedScore is the chance of a beating b according to Elo
+func expectedScore(a, b float64) float64 {
+	return 1 / (1 + math.Pow(10, (b-a)/400))
+}
+
+// RecordGame updates both players' ratings after a rated game. score is from
+// player 1's point of view: 1 for a win, 0.5 for a draw and 0 for a loss.
+func (s *AccountStore) RecordGame(player1, player2 string, score float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok1 := s.accounts[player1]
+	b, ok2 := s.accounts[player2]
+	if !ok1 || !ok2 || a == b {
+		return nil
+	}
+
+	// both changes are worked out from the ratings before the game
+	deltaA := kFactor * (score - expectedScore(a.Rating, b.Rating))
+	deltaB := kFactor * ((1 - score) - expectedScore(b.Rating, a.Rating))
+
+	now := time.Now()
+	a.addResult(now, b.Username, score, deltaA)
+	b.addResult(now, a.Username, 1-score, deltaB)
+
+	return s.save()
+}
+
+func (a *Account) addResult(when time.Time, opponent string, score, delta float64) {
+	change := RatingChange{
+		Time:     when,
+		Opponent: opponent,
+		Before:   a.Rating,
+		After:    a.Rating + delta,
+	}
+
+	switch score {
+	case 1:
+		change.Result = "win"
+		a.Wins++
+	case 0:
+		change.Result = "loss"
+		a.Losses++
+	default:
+		change.Result = "draw"
+		a.Draws++
+	}
+
+	a.Rating = change.After
+	a.History = append(a.History, change)
+}
+
+// Leaderboard returns the top rated accounts that have played at least once
+func (s *AccountStore) Leaderboard() game.Leaderboard {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []*Account
+	for _, a := range s.accounts {
+		if a.Games() > 0 {
+			list = append(list, a)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Rating != list[j].Rating {
+			return list[i].Rating > list[j].Rating
+		}
+		return list[i].Username < list[j].Username
+	})
+	if len(list) > leaderboardSize {
+		list = list[:leaderboardSize]
+	}
+
+	board := game.Leaderboard{}
+	for i, a := range list {
+		board.Entries = append(board.Entries, game.LeaderboardEntry{
+			Rank:     i + 1,
+			Username: a.Username,
+			Rating:   int(math.Round(a.Rating)),
+			Games:    a.Games(),
+			Wins:     a.Wins,
+			Losses:   a.Losses,
+			Draws:    a.Draws,
+			Bot:      a.Bot,
+		})
+	}
+	return board
+}
+
+// Ratings looks up the current rating of each name
+func (s *AccountStore) Ratings(names []string) map[string]float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ratings := map[string]float64{}
+	for _, name := range names {
+		if a, ok := s.accounts[name]; ok {
+			ratings[name] = a.Rating
+		}
+	}
+	return ratings
+}
+
+func (a *Account) Games() int {
+	return a.Wins + a.Losses + a.Draws
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// testAccounts is an account store in a temp dir with names registered
+func testAccounts(t *testing.T, names ...string) *AccountStore {
+	t.Helper()
+	s, err := LoadAccounts(filepath.Join(t.TempDir(), accountsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if _, err := s.Register(name, "password", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 0.01
+}
+
+func TestExpectedScore(t *testing.T) {
+	tests := []struct {
+		a, b, want float64
+	}{
+		{1200, 1200, 0.5},
+		{1600, 1200, 10.0 / 11},
+		{1200, 1600, 1.0 / 11},
+		{1400, 1200, 0.76},
+	}
+	for _, tt := range tests {
+		if got := expectedScore(tt.a, tt.b); !near(got, tt.want) {
+			t.Errorf("expectedScore(%v, %v) = %.3f, want %.3f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRecordGame(t *testing.T) {
+	tests := []struct {
+		name           string
+		ann, bob       float64 // ratings before
+		score          float64 // for ann
+		wantAnn, wantB float64
+	}{
+		{"even, ann wins", 1200, 1200, 1, 1216, 1184},
+		{"even, draw", 1200, 1200, 0.5, 1200, 1200},
+		{"even, bob wins", 1200, 1200, 0, 1184, 1216},
+		{"favourite wins", 1600, 1200, 1, 1602.91, 1197.09},
+		{"upset", 1600, 1200, 0, 1570.91, 1229.09},
+		{"favourite draws", 1600, 1200, 0.5, 1586.91, 1213.09},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := testAccounts(t, "ann", "bob")
+			s.accounts["ann"].Rating, s.accounts["bob"].Rating = tt.ann, tt.bob
+			if err := s.RecordGame("ann", "bob", tt.score); err != nil {
+				t.Fatal(err)
+			}
+			ann, bob := s.accounts["ann"], s.accounts["bob"]
+			if !near(ann.Rating, tt.wantAnn) || !near(bob.Rating, tt.wantB) {
+				t.Errorf("ratings %.2f and %.2f, want %.2f and %.2f", ann.Rating, bob.Rating, tt.wantAnn, tt.wantB)
+			}
+			// nothing gets made or lost
+			if !near(ann.Rating+bob.Rating, tt.ann+tt.bob) {
+				t.Errorf("ratings add up to %.2f", ann.Rating+bob.Rating)
+			}
+			if ann.Games() != 1 || bob.Games() != 1 || len(ann.History) != 1 || ann.History[0].Opponent != "bob" {
+				t.Errorf("ann's history %+v", ann.History)
+			}
+		})
+	}
+}
+
+func TestRecordGameResults(t *testing.T) {
+	s := testAccounts(t, "ann", "bob")
+	for _, score := range []float64{1, 0.5, 0, 1} {
+		if err := s.RecordGame("ann", "bob", score); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ann, bob := s.accounts["ann"], s.accounts["bob"]
+	if ann.Wins != 2 || ann.Draws != 1 || ann.Losses != 1 || bob.Wins != 1 || bob.Losses != 2 {
+		t.Errorf("ann %d/%d/%d, bob %d/%d/%d", ann.Wins, ann.Draws, ann.Losses, bob.Wins, bob.Draws, bob.Losses)
+	}
+	results := []string{"win", "draw", "loss", "win"}
+	for i, c := range ann.History {
+		if c.Result != results[i] {
+			t.Errorf("game %d is a %s, want %s", i+1, c.Result, results[i])
+		}
+		if i > 0 && c.Before != ann.History[i-1].After {
+			t.Errorf("game %d starts at %.2f, the last one ended at %.2f", i+1, c.Before, ann.History[i-1].After)
+		}
+	}
+
+	// games against nobody or yourself don't count
+	s.RecordGame("ann", "ann", 1)
+	s.RecordGame("ann", "nobody", 1)
+	if ann.Games() != 4 {
+		t.Errorf("ann has %d games, want 4", ann.Games())
+	}
+
+	// and they're saved
+	loaded, err := LoadAccounts(s.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.accounts["ann"]; got.Rating != ann.Rating || len(got.History) != 4 {
+		t.Errorf("loaded ann at %.2f with %d games", got.Rating, len(got.History))
+	}
+}
+
+func TestLeaderboard(t *testing.T) {
+	s := testAccounts(t, "ann", "bob", "cat", "dan")
+	s.RecordGame("ann", "bob", 1)
+	s.RecordGame("cat", "bob", 0.5)
+	board := s.Leaderboard()
+	var names []string
+	for i, e := range board.Entries {
+		names = append(names, e.Username)
+		if e.Rank != i+1 {
+			t.Errorf("%s is rank %d, want %d", e.Username, e.Rank, i+1)
+		}
+	}
+	// dan hasn't played so isn't on it
+	want := []string{"ann", "cat", "bob"}
+	if len(names) != len(want) {
+		t.Fatalf("leaderboard %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("leaderboard %v, want %v", names, want)
+		}
+	}
+	if board.Entries[0].Rating != 1216 || board.Entries[0].Wins != 1 {
+		t.Errorf("ann %+v", board.Entries[0])
+	}
+}
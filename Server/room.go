@@ -0,0 +1,322 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"tictactoe/game"
+)
+
+// Room is one game of tic tac toe. Quick match rooms are open to anybody,
+// tournament rooms are reserved for the two players of a pairing. There's a
+// seat for every player the rules are for.
+type Room struct {
+	ID      int
+	rules   game.Settings
+	state   game.State // the game itself, what's in it is up to the variant
+	moves   []game.Input
+	players []*client
+
+	// people watching, they get every update and can chat but can't move
+	spectators map[*client]bool
+	chat       []game.Message
+
+	// a bot's move clock, and the result if it ran out
+	clock   *time.Timer
+	forfeit string
+
+	// set for tournament rooms
+	reserved   [2]string
+	tournament *Tournament
+	pairing    *Pairing
+	noShow     *time.Timer // runs while a seat is empty, see waitForPlayers
+}
+
+// every room that has someone in it or is waiting for a tournament match.
+// Like everything else it's guarded by mu.
+var (
+	rooms      = map[int]*Room{}
+	nextRoomID = 1
+)
+
+func newRoom(rules game.Settings) *Room {
+	r := &Room{
+		ID:         nextRoomID,
+		rules:      rules,
+		players:    make([]*client, rules.Seats()),
+		spectators: map[*client]bool{},
+	}
+	r.start()
+	nextRoomID++
+	rooms[r.ID] = r
+	return r
+}
+
+// quickMatch finds a public room with the same rules where somebody is
+// waiting for an opponent, or opens a new one. That can be somebody whose
+// last opponent left after the game, seat starts them a new one.
+func quickMatch(rules game.Settings) *Room {
+	for _, r := range rooms {
+		if r.tournament != nil || r.rules != rules {
+			continue
+		}
+		for _, p := range r.players {
+			if p == nil {
+				return r
+			}
+		}
+	}
+	return newRoom(rules)
+}
+
+// seat puts the client in a free seat and tells everyone about it
+func (r *Room) seat(c *client) error {
+	free := -1
+	for i, p := range r.players {
+		if p != nil && p.name == c.name {
+			return errors.New("you are already playing from somewhere else")
+		}
+		// tournament rooms keep each seat for one player
+		if r.tournament != nil && r.reserved[i] != c.name {
+			continue
+		}
+		if p == nil && free == -1 {
+			free = i
+		}
+	}
+	if free == -1 {
+		if r.tournament != nil {
+			return errors.New("that isn't your match")
+		}
+		return errors.New("maximum players connected")
+	}
+
+	r.players[free] = c
+	c.room = r
+	c.player = free + 1
+
+	// assign a player id to the current connection.
+	if err := c.send(game.TypeWelcome, game.Welcome{Room: r.ID, Rules: r.rules, Player: c.player, Names: r.names()}); err != nil {
+		r.players[free] = nil
+		c.room = nil
+		return err
+	}
+	r.sendHistory(c)
+	// somebody new at a finished quick match gets a fresh board
+	if r.tournament == nil && r.checkWin() != "" {
+		r.start()
+	}
+	r.post(game.Message{Text: c.name + " sat down"})
+	r.waitForPlayers()
+	r.broadcast()
+	return nil
+}
+
+// watch lets the client follow the game without a seat
+func (r *Room) watch(c *client) error {
+	r.spectators[c] = true
+	c.room = r
+	c.player = 0
+
+	if err := c.send(game.TypeWelcome, game.Welcome{Room: r.ID, Rules: r.rules, Player: 0, Names: r.names()}); err != nil {
+		delete(r.spectators, c)
+		c.room = nil
+		return err
+	}
+	r.sendHistory(c)
+	r.post(game.Message{Text: c.name + " is watching"})
+	r.broadcast()
+	return nil
+}
+
+// sendHistory catches somebody who just came in up on the chat
+func (r *Room) sendHistory(c *client) {
+	for _, m := range r.chat {
+		c.send(game.TypeChat, m)
+	}
+}
+
+// leave frees the client's seat so somebody else can sit down. Walking out
+// of a rated game, tournament ones included, before it's over loses it.
+func (r *Room) leave(c *client) {
+	fmt.Println("Player left:", r.ID, c.player, c.name)
+	msg := c.name + " left"
+	if c.player != 0 && r.rated() && r.checkWin() == "" {
+		r.forfeit = fmt.Sprintf("Player %d", 3-c.player)
+		msg += " and forfeits"
+		r.finish()
+	}
+	if c.player == 0 {
+		delete(r.spectators, c)
+	} else {
+		r.players[c.player-1] = nil
+	}
+	c.room = nil
+	c.player = 0
+
+	// empty quick match rooms go away, tournament rooms stay until the
+	// pairing has a result
+	if len(r.everyone()) == 0 && (r.tournament == nil || r.pairing.Result != 0) {
+		delete(rooms, r.ID)
+		return
+	}
+	r.post(game.Message{Text: msg})
+	r.waitForPlayers()
+	r.broadcast()
+}
+
+// everyone is the players and the spectators
+func (r *Room) everyone() []*client {
+	var list []*client
+	for _, p := range r.players {
+		if p != nil {
+			list = append(list, p)
+		}
+	}
+	for c := range r.spectators {
+		list = append(list, c)
+	}
+	return list
+}
+
+// move plays a move for c. Moves out of turn are ignored, the client already
+// knows it isn't its turn, breaking the rules of a variant is an error.
+func (r *Room) move(c *client, input game.Input) error {
+	// the seat comes from the login, not from whatever the client claims
+	input.Player = c.player
+
+	fmt.Printf("Room %d player %d move: row=%d, col=%d\n", r.ID, input.Player, input.Row, input.Col)
+
+	// check if the player is allowed to make a move
+	turn := r.state.CurrentTurn()
+	expectedPlayer := r.state.ToMove()
+
+	if input.Player != expectedPlayer {
+		fmt.Println("Player tried to move twice or more on one turn", input.Player, turn, expectedPlayer)
+		return nil
+	}
+	if r.checkWin() != "" {
+		return nil
+	}
+	if err := r.state.Apply(input); err != nil {
+		return err
+	}
+	r.moves = append(r.moves, input)
+
+	r.finish()
+	r.broadcast()
+	return nil
+}
+
+// finish updates the ratings and the tournament once the game's over
+func (r *Room) finish() {
+	if winner := r.checkWin(); winner != "" {
+		r.rate(winner)
+		if r.tournament != nil {
+			r.tournament.record(r, winner)
+		}
+	}
+}
+
+// rated is whether the game counts for the ratings: both seats are taken
+// by two different accounts, and only two player games count
+func (r *Room) rated() bool {
+	return len(r.players) == 2 && r.players[0] != nil && r.players[1] != nil
+}
+
+// rate records a finished game for both players, if it's rated
+func (r *Room) rate(winner string) {
+	if !r.rated() {
+		return
+	}
+
+	score := 0.5
+	switch winner {
+	case "Player 1":
+		score = 1
+	case "Player 2":
+		score = 0
+	}
+
+	if err := accounts.RecordGame(r.players[0].name, r.players[1].name, score); err != nil {
+		fmt.Println("error saving ratings:", err)
+	}
+}
+
+// rematch clears the board once the current game is over. Tournament games
+// only count once, drawn knockout games get replayed without asking.
+func (r *Room) rematch() {
+	if r.checkWin() == "" || r.tournament != nil {
+		return
+	}
+	r.start()
+	r.broadcast()
+}
+
+// broadcast sends the board to everyone in the room. Each player gets their
+// own seat number in Player, spectators get 0.
+func (r *Room) broadcast() {
+	moveTime := r.startClock()
+	winner := r.checkWin()
+	state, err := r.state.Save()
+	if err != nil {
+		fmt.Println("error saving game state:", err)
+	}
+	for _, p := range r.everyone() {
+		update := game.Update{
+			Player:   p.player,
+			Rules:    r.rules,
+			Board:    r.state.Grid(),
+			Turn:     r.state.CurrentTurn(),
+			ToMove:   r.state.ToMove(),
+			Winner:   winner,
+			Names:    r.names(),
+			Bots:     r.bots(),
+			MoveTime: moveTime,
+			NoHints:  r.rated(), // no getting help in a game that counts
+			Moves:    r.moves,
+			State:    state,
+		}
+		if err := p.send(game.TypeUpdate, update); err != nil {
+			fmt.Println(err)
+		}
+	}
+}
+
+// names lists who is sitting in each seat. Empty seats of a tournament room
+// show who the seat is kept for.
+func (r *Room) names() []string {
+	list := make([]string, len(r.players))
+	for i, p := range r.players {
+		if p != nil {
+			list[i] = p.name
+		} else if i < len(r.reserved) {
+			list[i] = r.reserved[i]
+		}
+	}
+	return list
+}
+
+// checkWin is "Player N" for the winner, "CAT" for a draw and "" while the
+// game is still going. What counts as a win is up to the variant.
+func (r *Room) checkWin() string {
+	if r.forfeit != "" {
+		return r.forfeit
+	}
+	return r.state.Outcome()
+}
+
+// start sets up a new game. The rules were checked when the room was made so
+// the variant is always there.
+func (r *Room) start() {
+	state, err := r.rules.Start()
+	if err != nil {
+		fmt.Println("error starting game:", err)
+		r.rules = game.Classic
+		state, _ = r.rules.Start()
+	}
+	r.state = state
+	r.moves = nil
+	r.forfeit = ""
+}
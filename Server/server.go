@@ -2,192 +2,337 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
+	"sync"
+	"time"
+
+	"tictactoe/game"
 )
 
-type Input struct {
-        Player int
-        Row    int
-        Col    int
+// every logged in connection, whether it's in a room or not. mu guards
+// clients, rooms and tournaments since every connection has its own
+// goroutine.
+var (
+	clients = map[*client]bool{}
+	mu      sync.Mutex
+)
+
+var accounts *AccountStore
+
+// variantsDir has the house rule variants, one .json file each
+const variantsDir = "variants"
+
+// variants from variantsDir, sent to everybody when they log in
+var variants game.Variants
+
+// how many messages can be waiting to go out to a client. One that falls
+// that far behind gets cut off instead of holding everybody else up.
+const sendQueue = 256
+
+// client is one connection to the server
+type client struct {
+	conn   net.Conn
+	enc    *json.Encoder      // only write uses it
+	out    chan game.Envelope // what send queued for write
+	dec    *json.Decoder
+	name   string // account the connection logged in as
+	room   *Room  // nil while in the menu
+	player int    // seat number in room, 0 when just watching
+	bot    bool   // the account is a bot, it gets a clock
+
+	// for the chat spam check
+	chatTimes    []time.Time
+	lastChat     string
+	lastChatTime time.Time
 }
 
-type Update struct {
-        Player int
-        Board  [3][3]int
-        Turn   int
-        Winner string
+func main() {
+	// listen
+	// accept
+	// handle connections
+
+	var err error
+	accounts, err = LoadAccounts(accountsFile)
+	if err != nil {
+		fmt.Println("error loading accounts:", err)
+		return
+	}
+	// before the tournaments since those can be using them
+	variants.List, err = game.LoadVariants(variantsDir)
+	if err != nil {
+		fmt.Println("error loading variants:", err)
+		return
+	}
+	if err := LoadTournaments(tournamentsFile); err != nil {
+		fmt.Println("error loading tournaments:", err)
+		return
+	}
+
+	fmt.Println("Server is up and running. Waiting for players to connect.")
+
+	dstream, err := net.Listen("tcp", "100.67.88.56:8080")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dstream.Close()
+
+	for {
+		conn, err := dstream.Accept()
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		go handle(conn)
+	}
 }
 
-// global variables
-var (
-        board [3][3]int
-        turn  = 1
-)
+func handle(conn net.Conn) {
+	defer conn.Close() // close the connection after the go routine finishes
+
+	c := &client{
+		conn: conn,
+		enc:  json.NewEncoder(conn),
+		out:  make(chan game.Envelope, sendQueue),
+		dec:  json.NewDecoder(conn),
+	}
+	go c.write()
+	// runs after the cleanup below, by then nothing can send to c any more
+	defer close(c.out)
+
+	// nobody gets in before they have logged in
+	if err := c.authenticate(); err != nil {
+		fmt.Println("Login error:", err)
+		return
+	}
+
+	fmt.Println("Player connected:", c.name)
+
+	if err := c.send(game.TypeVariants, variants); err != nil {
+		fmt.Println("Send error:", err)
+		return
+	}
+
+	mu.Lock()
+	clients[c] = true
+	mu.Unlock()
+
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if c.room != nil {
+			c.room.leave(c)
+		}
+		delete(clients, c)
+	}()
+
+	for {
+		var env game.Envelope
+		if err := c.dec.Decode(&env); err != nil {
+			fmt.Println("Decode error:", err)
+			return
+		}
+
+		mu.Lock()
+		err := c.dispatch(env)
+		mu.Unlock()
+		if err != nil {
+			fmt.Println(c.name, err)
+		}
+	}
+}
 
-var conn1 net.Conn = nil
-var conn2 net.Conn = nil
+// send queues a message for the client. It never waits on the connection,
+// so it's fine to call with mu held.
+func (c *client) send(typ string, v any) error {
+	env, err := game.Wrap(typ, v)
+	if err != nil {
+		return err
+	}
+	select {
+	case c.out <- env:
+		return nil
+	default:
+		c.conn.Close() // its next read fails and handle cleans up
+		return errors.New(c.name + " isn't keeping up, disconnecting")
+	}
+}
 
-func main() {
-        // listen
-        // accept
-        // handle connections
-        playercount := 0
-
-        fmt.Println("Server is up and running. Waiting for players to connect.")
-
-        dstream, err := net.Listen("tcp", "100.67.88.56:8080")
-        if err != nil {
-                fmt.Println(err)
-                return
-        }
-        defer dstream.Close()
-
-        for {
-                conn, err := dstream.Accept()
-                if err != nil {
-                        fmt.Println(err)
-                        continue
-                }
-                playercount++
-                playernum := playercount
-
-                fmt.Println("Player connected:", playernum)
-
-                // distinguish between the connections
-                if playernum == 1 {
-                        conn1 = conn
-                } else if playernum == 2 {
-                        conn2 = conn
-                } else {
-                        fmt.Println("Maximum players connected. Connection refused.")
-                        conn.Close() // reject the connection
-                }
-
-                go func(conn net.Conn, playernum int) {
-
-                        fmt.Println("Started goroutine for player ", playernum)
-
-                        defer conn.Close() // close the connection after the go routine finishes
-                        encoder := json.NewEncoder(conn)
-                        decoder := json.NewDecoder(conn)
-
-                        // assign a player id to the current connection.
-                        if err := encoder.Encode(struct {Player int}{Player: playernum}); err != nil {
-                                fmt.Println("error sending player number", err)
-                                return
-                        }
-
-                        for {
-                                var input Input
-
-                                // decode the input from the player clicking on the board
-                                if err := decoder.Decode(&input); err != nil {
-                                        fmt.Println("Decode error:", err)
-                                        return
-                                }
-
-                                fmt.Printf("Player %d move: row=%d, col=%d\n", input.Player, input.Row, input.Col)
-
-                                // check if the player is allowed to make a move
-                                expectedPlayer := 1
-                                if turn % 2 == 0 {
-                                        expectedPlayer = 2
-                                }
-
-                                if input.Player != expectedPlayer {
-                                        fmt.Println("Player tried to move twice or more on one turn", input.Player, turn, expectedPlayer)
-                                        continue
-                                }
-
-
-                                if board[input.Row][input.Col] == 0 {
-                                board[input.Row][input.Col] = input.Player
-                                turn++
-                                }
-                                winner := checkWin()
-
-                                update := Update {
-                                Player: input.Player,
-                                Board: board,
-                                Turn:  turn,
-                                Winner: winner,
-                                }
-
-
-                                fmt.Println("currentUpdate values: ", update)
-
-                                // update the current player
-                                if err := encoder.Encode(update); err != nil {
-                                        fmt.Println(err)
-                                        return
-                                }
-
-                        if playernum == 1 {
-
-                                otherPlayerUpdate := Update {
-                                        Player: input.Player + 1,
-                                        Board: board,
-                                        Turn:  turn,
-                                        Winner: winner,
-                                }
-                                // notify player 2
-                                otherConn := conn2
-                                otherEncoder := json.NewEncoder(otherConn)
-                                if err := otherEncoder.Encode(otherPlayerUpdate); err != nil {
-                                        fmt.Println(err)
-                                        return
-                                }
-                        }
-                        if playernum == 2 {
-                                otherPlayerUpdate := Update {
-                                        Player: input.Player - 1,
-                                        Board: board,
-                                        Turn:  turn,
-                                        Winner: winner,
-                                }
-                                //notify player 1
-                                otherConn := conn1
-                                otherEncoder := json.NewEncoder(otherConn)
-                                if err := otherEncoder.Encode(otherPlayerUpdate); err != nil {
-                                        fmt.Println(err)
-                                        return
-                                }
-                        }
-
-                        }
-               }(conn, playernum)
-        }
+// write sends what's queued until out is closed. After an error it keeps
+// reading so send never fills the queue up.
+func (c *client) write() {
+	var err error
+	for env := range c.out {
+		if err == nil {
+			err = c.enc.Encode(env)
+		}
+	}
+}
+
+// dispatch handles one message from a logged in client. Errors worth telling
+// the player about are sent back to them. Must be called with mu held.
+func (c *client) dispatch(env game.Envelope) error {
+	var err error
+
+	switch env.Type {
+	case game.TypeJoin:
+		var join game.Join
+		if err = env.Open(&join); err == nil {
+			err = c.join(join)
+		}
+
+	case game.TypeLeave:
+		if c.room != nil {
+			c.room.leave(c)
+		}
+
+	case game.TypeMove:
+		var input game.Input
+		if err = env.Open(&input); err == nil && c.room != nil {
+			err = c.room.move(c, input)
+		}
+
+	case game.TypeRematch:
+		if c.room != nil && c.player != 0 {
+			c.room.rematch()
+		}
+
+	case game.TypeChat:
+		var msg game.Message
+		if err = env.Open(&msg); err == nil {
+			if c.room == nil {
+				err = errors.New("join a game to chat")
+			} else {
+				err = c.room.say(c, msg.Text)
+			}
+		}
+
+	case game.TypeLeaderboard:
+		return c.send(game.TypeLeaderboard, accounts.Leaderboard())
+
+	case game.TypeTournaments:
+		return c.send(game.TypeTournaments, tournamentList())
+
+	case game.TypeCreateTournament, game.TypeJoinTournament, game.TypeStartTournament:
+		var req game.TournamentRequest
+		if err = env.Open(&req); err == nil {
+			err = c.tournament(env.Type, req)
+		}
+
+	default:
+		return errors.New("unknown message " + env.Type)
+	}
+
+	if err != nil {
+		return c.send(game.TypeError, game.Error{Text: err.Error()})
+	}
+	return nil
+}
+
+// join sits the client down in a room, 0 means any quick match room with
+// the rules they asked for. With Watch set they come in as a spectator
+// instead. Asking for the room you're already in just sends the board again.
+func (c *client) join(join game.Join) error {
+	id := join.Room
+	if join.Rules == (game.Settings{}) {
+		join.Rules = game.Classic
+	}
+	if err := join.Rules.Validate(); err != nil {
+		return err
+	}
+
+	if c.room != nil {
+		sameQuickMatch := id == 0 && c.room.tournament == nil && c.room.rules == join.Rules && c.player != 0
+		if sameQuickMatch || id == c.room.ID {
+			c.room.broadcast()
+			return nil
+		}
+		c.room.leave(c)
+	}
+
+	var r *Room
+	if id == 0 {
+		r = quickMatch(join.Rules)
+	} else if r = rooms[id]; r == nil {
+		return errors.New("that game is over")
+	}
+
+	if join.Watch {
+		return r.watch(c)
+	}
+	if err := r.seat(c); err != nil {
+		if r.tournament == nil && len(r.everyone()) == 0 {
+			delete(rooms, r.ID)
+		}
+		return err
+	}
+	fmt.Println("Player seated:", r.ID, c.player, c.name)
+	return nil
+}
+
+func (c *client) tournament(typ string, req game.TournamentRequest) error {
+	var t *Tournament
+	var err error
+
+	switch typ {
+	case game.TypeCreateTournament:
+		t, err = createTournament(c.name, req.Format, req.Rules)
+	case game.TypeJoinTournament:
+		if t, err = findTournament(req.ID); err == nil {
+			err = t.join(c.name)
+		}
+	case game.TypeStartTournament:
+		if t, err = findTournament(req.ID); err == nil {
+			err = t.start(c.name)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	saveTournaments()
+	broadcastTournaments()
+	return nil
 }
 
-func checkWin() string {
-
-        for i := 0; i < 3; i++ {
-                 if board[i][0] == board[i][1] && board[i][1] == board[i][2] && board[i][0] != 0 {
-                        return fmt.Sprintf("Player %d", board[i][0])
-                 }
-                 if board[0][i] == board[1][i] && board[1][i] == board[2][i] && board[0][i] != 0 {
-                        return fmt.Sprintf("Player %d", board[0][i])
-                 }
-        }
-
-        if board [0][0] == board[1][1] && board[1][1] == board[2][2] && board[0][0] != 0 {
-                return fmt.Sprintf("Player %d", board[0][0])
-        }
-        if board [0][2] == board[1][1] && board[1][1] == board[2][0] && board[0][2] != 0 {
-                return fmt.Sprintf("Player %d", board[0][2])
-        }
-
-        full := true
-        for i := 0; i < 3; i++ {
-                for j := 0; j < 3; j++ {
-                        if board[i][j] == 0 {
-                                full = false
-                        }
-                }
-        }
-        if full {
-                return "CAT"
-        }
-        return ""
-}
\ No newline at end of file
+// authenticate reads register and login requests until one of them works
+func (c *client) authenticate() error {
+	for {
+		var env game.Envelope
+		if err := c.dec.Decode(&env); err != nil {
+			return err
+		}
+		var cred game.Credentials
+		if err := env.Open(&cred); err != nil {
+			return err
+		}
+
+		name := cred.Username
+		var token string
+		var err error
+		switch {
+		case env.Type == game.TypeRegister:
+			token, err = accounts.Register(cred.Username, cred.Password, cred.Bot)
+		case env.Type == game.TypeLogin && cred.Token != "":
+			token = cred.Token
+			name, err = accounts.Resume(cred.Token)
+		case env.Type == game.TypeLogin:
+			token, err = accounts.Login(cred.Username, cred.Password)
+		default:
+			err = errors.New("please log in first")
+		}
+
+		if err != nil {
+			if err := c.send(game.TypeAuth, game.AuthResult{Error: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		c.name = name
+		c.bot = accounts.IsBot(name)
+		return c.send(game.TypeAuth, game.AuthResult{OK: true, Username: name, Token: token})
+	}
+}
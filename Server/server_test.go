@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"tictactoe/game"
+)
+
+// a client that stops reading gets cut off, sending to it never blocks
+func TestSendDoesntBlock(t *testing.T) {
+	conn, other := net.Pipe()
+	defer other.Close()
+	c := &client{name: "slow", conn: conn, enc: json.NewEncoder(conn), out: make(chan game.Envelope, sendQueue)}
+	go c.write()
+	defer close(c.out)
+
+	done := make(chan error)
+	go func() {
+		var err error
+		for i := 0; i < 2*sendQueue && err == nil; i++ {
+			err = c.send(game.TypeChat, game.Message{Text: "hi"})
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("the queue never filled up")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("send blocked")
+	}
+
+	// and it was disconnected
+	if _, err := other.Read(make([]byte, 1)); err == nil {
+		t.Error("the connection is still open")
+	}
+}
+
+// a client that keeps up gets everything in order
+func TestSendOrder(t *testing.T) {
+	conn, other := net.Pipe()
+	defer other.Close()
+	c := &client{conn: conn, enc: json.NewEncoder(conn), out: make(chan game.Envelope, sendQueue)}
+	go c.write()
+	defer close(c.out)
+
+	for _, text := range []string{"one", "two", "three"} {
+		if err := c.send(game.TypeChat, game.Message{Text: text}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dec := json.NewDecoder(other)
+	for _, want := range []string{"one", "two", "three"} {
+		var env game.Envelope
+		var m game.Message
+		if err := dec.Decode(&env); err != nil {
+			t.Fatal(err)
+		}
+		if err := env.Open(&m); err != nil || m.Text != want {
+			t.Errorf("got %q, want %q", m.Text, want)
+		}
+	}
+}
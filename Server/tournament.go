@@ -0,0 +1,592 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"tictactoe/game"
+)
+
+// where tournaments get saved so a restart doesn't lose the results
+const tournamentsFile = "tournaments.json"
+
+const (
+	formatRoundRobin  = game.FormatRoundRobin
+	formatElimination = game.FormatElimination
+)
+
+const (
+	statusRegistering = "registering"
+	statusRunning     = "running"
+	statusFinished    = "finished"
+)
+
+// results of a pairing
+const (
+	resultPending = iota
+	resultPlayer1
+	resultPlayer2
+	resultDraw
+)
+
+// a drawn knockout game is replayed this many times before the better seed
+// goes through
+const maxReplays = 2
+
+// how long a tournament match waits for a player who isn't there before
+// they lose it
+const noShowTime = 5 * time.Minute
+
+type Tournament struct {
+	ID      int
+	Name    string
+	Format  string
+	Rules   game.Settings
+	Creator string
+	Status  string
+	Players []string // seed order once the tournament has started
+	Rounds  [][]*Pairing
+	Winner  string
+}
+
+// Pairing is one match in a round. Player2 is "" when Player1 has a bye.
+type Pairing struct {
+	Player1 string
+	Player2 string
+	Result  int
+	Replays int
+
+	room *Room
+}
+
+var tournaments []*Tournament
+
+// LoadTournaments reads the saved tournaments and opens rooms again for any
+// match that was still being played when the server stopped.
+// Must be called before the server starts accepting connections.
+func LoadTournaments(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &tournaments); err != nil {
+		return err
+	}
+
+	for _, t := range tournaments {
+		// files from before boards had sizes have no rules, and a variant
+		// could have gone away since the file was saved
+		if t.Rules == (game.Settings{}) || t.Rules.Validate() != nil {
+			t.Rules = game.Classic
+		}
+		if t.Status == statusRunning {
+			t.startRound()
+		}
+	}
+	return nil
+}
+
+// saveTournaments must be called with mu held
+func saveTournaments() {
+	data, err := json.MarshalIndent(tournaments, "", "  ")
+	if err == nil {
+		tmp := tournamentsFile + ".tmp"
+		if err = os.WriteFile(tmp, data, 0644); err == nil {
+			err = os.Rename(tmp, tournamentsFile)
+		}
+	}
+	if err != nil {
+		fmt.Println("error saving tournaments:", err)
+	}
+}
+
+func findTournament(id int) (*Tournament, error) {
+	for _, t := range tournaments {
+		if t.ID == id {
+			return t, nil
+		}
+	}
+	return nil, errors.New("no such tournament")
+}
+
+func createTournament(creator, format string, rules game.Settings) (*Tournament, error) {
+	if format != formatRoundRobin && format != formatElimination {
+		return nil, errors.New("unknown tournament format " + format)
+	}
+	if rules == (game.Settings{}) {
+		rules = game.Classic
+	}
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+	if rules.Seats() != 2 {
+		return nil, errors.New("tournament games are for two players")
+	}
+
+	id := 1
+	if len(tournaments) > 0 {
+		id = tournaments[len(tournaments)-1].ID + 1
+	}
+	t := &Tournament{
+		ID:      id,
+		Name:    fmt.Sprintf("Tournament %d", id),
+		Format:  format,
+		Rules:   rules,
+		Creator: creator,
+		Status:  statusRegistering,
+		Players: []string{creator},
+	}
+	tournaments = append(tournaments, t)
+	return t, nil
+}
+
+func (t *Tournament) join(name string) error {
+	if t.Status != statusRegistering {
+		return errors.New("registration is closed")
+	}
+	for _, p := range t.Players {
+		if p == name {
+			return errors.New("you already joined")
+		}
+	}
+	t.Players = append(t.Players, name)
+	return nil
+}
+
+// start closes registration, seeds the players by rating and makes the
+// first round
+func (t *Tournament) start(by string) error {
+	if by != t.Creator {
+		return errors.New("only " + t.Creator + " can start it")
+	}
+	if t.Status != statusRegistering {
+		return errors.New("it has already started")
+	}
+	if len(t.Players) < 2 {
+		return errors.New("need at least 2 players")
+	}
+
+	ratings := accounts.Ratings(t.Players)
+	sort.SliceStable(t.Players, func(i, j int) bool {
+		return ratings[t.Players[i]] > ratings[t.Players[j]]
+	})
+
+	if t.Format == formatRoundRobin {
+		// the later rounds are added one at a time by advance
+		t.Rounds = roundRobin(t.Players)[:1]
+	} else {
+		t.Rounds = [][]*Pairing{bracket(t.Players)}
+	}
+	t.Status = statusRunning
+	t.startRound()
+	return nil
+}
+
+// roundRobin pairs everybody with everybody using the circle method. With an
+// odd number of players somebody sits out each round.
+func roundRobin(players []string) [][]*Pairing {
+	list := append([]string{}, players...)
+	if len(list)%2 == 1 {
+		list = append(list, "")
+	}
+	n := len(list)
+
+	var rounds [][]*Pairing
+	for r := 0; r < n-1; r++ {
+		var round []*Pairing
+		for i := 0; i < n/2; i++ {
+			a, b := list[i], list[n-1-i]
+			// swap who goes first every other round so it evens out
+			if r%2 == 1 {
+				a, b = b, a
+			}
+			if a == "" {
+				a, b = b, a
+			}
+			round = append(round, &Pairing{Player1: a, Player2: b})
+		}
+		rounds = append(rounds, round)
+
+		// keep the first player still and rotate everybody else
+		last := list[n-1]
+		copy(list[2:], list[1:n-1])
+		list[1] = last
+	}
+	return rounds
+}
+
+// bracket makes the first knockout round. The field is padded to a power of
+// two and the byes go to the top seeds, 1 plays 8, 4 plays 5 and so on.
+func bracket(players []string) []*Pairing {
+	size := 1
+	for size < len(players) {
+		size *= 2
+	}
+
+	order := []int{1}
+	for len(order) < size {
+		next := make([]int, 0, len(order)*2)
+		for _, seed := range order {
+			next = append(next, seed, len(order)*2+1-seed)
+		}
+		order = next
+	}
+
+	seeded := func(seed int) string {
+		if seed > len(players) {
+			return ""
+		}
+		return players[seed-1]
+	}
+
+	var round []*Pairing
+	for i := 0; i < size; i += 2 {
+		round = append(round, &Pairing{Player1: seeded(order[i]), Player2: seeded(order[i+1])})
+	}
+	return round
+}
+
+func (t *Tournament) current() []*Pairing {
+	return t.Rounds[len(t.Rounds)-1]
+}
+
+// startRound opens a room for every unplayed match of the current round and
+// tells the players it's ready
+func (t *Tournament) startRound() {
+	for _, p := range t.current() {
+		if p.Result != resultPending {
+			continue
+		}
+		if p.Player2 == "" {
+			p.Result = resultPlayer1
+			continue
+		}
+
+		r := newRoom(t.Rules)
+		r.tournament = t
+		r.pairing = p
+		r.reserved = [2]string{p.Player1, p.Player2}
+		r.waitForPlayers()
+		p.room = r
+
+		for i, name := range r.reserved {
+			match := game.Match{
+				Tournament: t.ID,
+				Name:       t.Name,
+				Round:      len(t.Rounds),
+				Room:       r.ID,
+				Opponent:   r.reserved[1-i],
+			}
+			for c := range clients {
+				if c.name == name {
+					c.send(game.TypeMatch, match)
+				}
+			}
+		}
+	}
+
+	// a round made only of byes is already over
+	t.advance()
+}
+
+// record is called by a tournament room when its game ends
+func (t *Tournament) record(r *Room, winner string) {
+	p := r.pairing
+	switch winner {
+	case "Player 1":
+		p.Result = resultPlayer1
+	case "Player 2":
+		p.Result = resultPlayer2
+	default:
+		p.Result = resultDraw
+	}
+
+	// knockout games need a winner. The replay starts by itself once
+	// everyone has seen the draw, a bot would never ask for it.
+	if p.Result == resultDraw && t.Format == formatElimination {
+		p.Replays++
+		if p.Replays <= maxReplays {
+			p.Result = resultPending
+			r.broadcast()
+			r.post(game.Message{Text: fmt.Sprintf("drawn, replay %d of %d", p.Replays, maxReplays)})
+			r.start()
+			return
+		}
+		// still drawn, the better seed goes through
+		p.Result = t.betterSeed(p)
+	}
+
+	p.room = nil
+	if len(r.everyone()) == 0 {
+		delete(rooms, r.ID)
+	}
+
+	t.advance()
+	saveTournaments()
+	broadcastTournaments()
+}
+
+// waitForPlayers starts the no-show clock of a tournament room that has an
+// empty seat, or stops it once both players are there. Without it a
+// pairing whose player never comes back would never finish.
+func (r *Room) waitForPlayers() {
+	if r.noShow != nil {
+		r.noShow.Stop()
+		r.noShow = nil
+	}
+	if r.tournament == nil || r.pairing.Result != resultPending || r.rated() {
+		return
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(noShowTime, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		// it could have gone off just before being stopped
+		if r.noShow == timer {
+			r.noShowTimeout()
+		}
+	})
+	r.noShow = timer
+}
+
+// noShowTimeout ends a match somebody didn't turn up for. Whoever is there
+// wins, if nobody is it's a draw, or in a knockout the better seed goes
+// through.
+func (r *Room) noShowTimeout() {
+	r.noShow = nil
+	p := r.pairing
+	if rooms[r.ID] != r || p.Result != resultPending || r.rated() {
+		return
+	}
+	switch {
+	case r.players[0] != nil:
+		r.forfeit = "Player 1"
+		r.post(game.Message{Text: p.Player2 + " didn't show up"})
+	case r.players[1] != nil:
+		r.forfeit = "Player 2"
+		r.post(game.Message{Text: p.Player1 + " didn't show up"})
+	case r.tournament.Format == formatElimination && r.tournament.betterSeed(p) == resultPlayer1:
+		r.forfeit = "Player 1"
+	case r.tournament.Format == formatElimination:
+		r.forfeit = "Player 2"
+	default:
+		r.forfeit = "CAT"
+	}
+	r.finish()
+	r.broadcast()
+}
+
+// advance moves on to the next round once every match of this one is done
+func (t *Tournament) advance() {
+	for _, p := range t.current() {
+		if p.Result == resultPending {
+			return
+		}
+	}
+
+	if t.Format == formatRoundRobin {
+		if len(t.Rounds) < len(roundRobin(t.Players)) {
+			all := roundRobin(t.Players)
+			t.Rounds = append(t.Rounds, all[len(t.Rounds)])
+			t.startRound()
+			return
+		}
+		t.Status = statusFinished
+		t.Winner = t.Standings()[0].Username
+		return
+	}
+
+	var winners []string
+	for _, p := range t.current() {
+		winners = append(winners, p.winner())
+	}
+	if len(winners) == 1 {
+		t.Status = statusFinished
+		t.Winner = winners[0]
+		return
+	}
+
+	var round []*Pairing
+	for i := 0; i < len(winners); i += 2 {
+		round = append(round, &Pairing{Player1: winners[i], Player2: winners[i+1]})
+	}
+	t.Rounds = append(t.Rounds, round)
+	t.startRound()
+}
+
+func (p *Pairing) winner() string {
+	switch p.Result {
+	case resultPlayer1:
+		return p.Player1
+	case resultPlayer2:
+		return p.Player2
+	}
+	return ""
+}
+
+// score is how many points name got out of the pairing
+func (p *Pairing) score(name string) float64 {
+	switch {
+	case p.Result == resultDraw:
+		return 0.5
+	case p.winner() == name:
+		return 1
+	}
+	return 0
+}
+
+// betterSeed is the result where the better seeded player of p wins
+func (t *Tournament) betterSeed(p *Pairing) int {
+	if t.seed(p.Player1) < t.seed(p.Player2) {
+		return resultPlayer1
+	}
+	return resultPlayer2
+}
+
+func (t *Tournament) seed(name string) int {
+	for i, p := range t.Players {
+		if p == name {
+			return i + 1
+		}
+	}
+	return len(t.Players) + 1
+}
+
+// Standings ranks the players. Round robin goes by points (a win is 1, a
+// draw is half), then Sonneborn-Berger (the points of everyone you beat plus
+// half the points of everyone you drew), then wins, then seed. Knockouts go
+// by how far you got, then seed.
+func (t *Tournament) Standings() []game.Standing {
+	rows := map[string]*game.Standing{}
+	for _, name := range t.Players {
+		rows[name] = &game.Standing{Username: name}
+	}
+
+	for round, pairings := range t.Rounds {
+		for _, p := range pairings {
+			if p.Result == resultPending || p.Player2 == "" {
+				if p.Player2 == "" && t.Format == formatElimination {
+					rows[p.Player1].Points++
+				}
+				continue
+			}
+			for _, name := range []string{p.Player1, p.Player2} {
+				row := rows[name]
+				row.Points += p.score(name)
+				switch {
+				case p.Result == resultDraw:
+					row.Draws++
+				case p.winner() == name:
+					row.Wins++
+				default:
+					row.Losses++
+					if t.Format == formatElimination {
+						row.Status = fmt.Sprintf("out in round %d", round+1)
+					}
+				}
+			}
+		}
+	}
+
+	if t.Format == formatRoundRobin {
+		for _, pairings := range t.Rounds {
+			for _, p := range pairings {
+				if p.Result == resultPending || p.Player2 == "" {
+					continue
+				}
+				a, b := rows[p.Player1], rows[p.Player2]
+				a.TieBreak += p.score(p.Player1) * b.Points
+				b.TieBreak += p.score(p.Player2) * a.Points
+			}
+		}
+	}
+
+	list := make([]game.Standing, 0, len(rows))
+	for _, row := range rows {
+		if row.Username == t.Winner {
+			row.Status = "champion"
+		}
+		list = append(list, *row)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		if a.TieBreak != b.TieBreak {
+			return a.TieBreak > b.TieBreak
+		}
+		if a.Wins != b.Wins {
+			return a.Wins > b.Wins
+		}
+		return t.seed(a.Username) < t.seed(b.Username)
+	})
+	for i := range list {
+		list[i].Rank = i + 1
+	}
+	return list
+}
+
+// Info is what the clients get to see of a tournament
+func (t *Tournament) Info() game.TournamentInfo {
+	info := game.TournamentInfo{
+		ID:        t.ID,
+		Name:      t.Name,
+		Format:    t.Format,
+		Rules:     t.Rules,
+		Creator:   t.Creator,
+		Status:    t.Status,
+		Winner:    t.Winner,
+		Players:   t.Players,
+		Round:     len(t.Rounds),
+		Standings: t.Standings(),
+	}
+	if len(t.Rounds) == 0 {
+		return info
+	}
+
+	for _, p := range t.current() {
+		pi := game.PairingInfo{Player1: p.Player1, Player2: p.Player2}
+		switch {
+		case p.Player2 == "":
+			pi.Result = "bye"
+		case p.Result == resultPlayer1:
+			pi.Result = "1-0"
+		case p.Result == resultPlayer2:
+			pi.Result = "0-1"
+		case p.Result == resultDraw:
+			pi.Result = "draw"
+		}
+		if p.room != nil {
+			pi.Room = p.room.ID
+		}
+		info.Pairings = append(info.Pairings, pi)
+	}
+	return info
+}
+
+func tournamentList() game.Tournaments {
+	list := game.Tournaments{}
+	for _, t := range tournaments {
+		list.List = append(list.List, t.Info())
+	}
+	return list
+}
+
+// broadcastTournaments sends the tournament list to everyone logged in.
+// Must be called with mu held.
+func broadcastTournaments() {
+	list := tournamentList()
+	for c := range clients {
+		if err := c.send(game.TypeTournaments, list); err != nil {
+			fmt.Println(err)
+		}
+	}
+}
@@ -0,0 +1,454 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"tictactoe/game"
+)
+
+// testServer starts the test with nothing going on and accounts for names
+func testServer(t *testing.T, names ...string) {
+	t.Helper()
+	t.Chdir(t.TempDir()) // tournaments get saved in the working dir
+	accounts = testAccounts(t, names...)
+	rooms, nextRoomID = map[int]*Room{}, 1
+	tournaments, clients = nil, map[*client]bool{}
+}
+
+// play makes moves in r for whoever's turn it is, cells like a1
+func play(t *testing.T, r *Room, cells ...string) {
+	t.Helper()
+	for _, cell := range cells {
+		pos, err := game.ParsePos(r.state.Grid(), cell)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := r.players[r.state.ToMove()-1]
+		if c == nil {
+			t.Fatalf("%s: nobody in seat %d", cell, r.state.ToMove())
+		}
+		if err := r.move(c, game.Input{Pos: pos}); err != nil {
+			t.Fatalf("%s: %v", cell, err)
+		}
+	}
+}
+
+// games that end each way on 3x3
+var (
+	player1Wins = []string{"a1", "a2", "b1", "b2", "c1"}
+	player2Wins = []string{"a1", "a2", "b1", "b2", "c3", "c2"}
+	drawn       = []string{"a1", "b1", "c1", "b2", "a2", "c2", "b3", "a3", "c3"}
+)
+
+func TestRoundRobin(t *testing.T) {
+	for n := 2; n <= 8; n++ {
+		t.Run(fmt.Sprintf("%d players", n), func(t *testing.T) {
+			var players []string
+			for i := 0; i < n; i++ {
+				players = append(players, fmt.Sprintf("p%d", i+1))
+			}
+			rounds := roundRobin(players)
+			want := n - 1
+			if n%2 == 1 {
+				want = n // everybody sits one out
+			}
+			if len(rounds) != want {
+				t.Fatalf("%d rounds, want %d", len(rounds), want)
+			}
+
+			met := map[[2]string]int{}
+			byes := map[string]int{}
+			for i, round := range rounds {
+				seen := map[string]bool{}
+				for _, p := range round {
+					if p.Player1 == "" {
+						t.Fatalf("round %d: a pairing without a first player", i+1)
+					}
+					if p.Player2 == "" {
+						byes[p.Player1]++
+					}
+					for _, name := range []string{p.Player1, p.Player2} {
+						if name != "" && seen[name] {
+							t.Fatalf("round %d: %s plays twice", i+1, name)
+						}
+						seen[name] = true
+					}
+					if p.Player2 != "" {
+						a, b := min(p.Player1, p.Player2), max(p.Player1, p.Player2)
+						met[[2]string{a, b}]++
+					}
+				}
+			}
+			for i, a := range players {
+				for _, b := range players[i+1:] {
+					if got := met[[2]string{min(a, b), max(a, b)}]; got != 1 {
+						t.Errorf("%s and %s play %d times", a, b, got)
+					}
+				}
+				if want := n % 2; byes[a] != want {
+					t.Errorf("%s has %d byes, want %d", a, byes[a], want)
+				}
+			}
+		})
+	}
+}
+
+func TestBracket(t *testing.T) {
+	tests := []struct {
+		players int
+		want    [][2]int // seeds, 0 is a bye
+	}{
+		{2, [][2]int{{1, 2}}},
+		{3, [][2]int{{1, 0}, {2, 3}}},
+		{4, [][2]int{{1, 4}, {2, 3}}},
+		{5, [][2]int{{1, 0}, {4, 5}, {2, 0}, {3, 0}}},
+		{6, [][2]int{{1, 0}, {4, 5}, {2, 0}, {3, 6}}},
+		{8, [][2]int{{1, 8}, {4, 5}, {2, 7}, {3, 6}}},
+	}
+	for _, tt := range tests {
+		var players []string
+		for i := 0; i < tt.players; i++ {
+			players = append(players, fmt.Sprint(i+1))
+		}
+		seed := func(n int) string {
+			if n == 0 {
+				return ""
+			}
+			return fmt.Sprint(n)
+		}
+		round := bracket(players)
+		if len(round) != len(tt.want) {
+			t.Errorf("%d players: %d pairings, want %d", tt.players, len(round), len(tt.want))
+			continue
+		}
+		for i, p := range round {
+			if p.Player1 != seed(tt.want[i][0]) || p.Player2 != seed(tt.want[i][1]) {
+				t.Errorf("%d players: pairing %d is %q against %q, want %v", tt.players, i+1, p.Player1, p.Player2, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestSonnebornBerger(t *testing.T) {
+	// bob is the top seed, but ann beat better players on the way to the
+	// same points
+	players := []string{"bob", "ann", "cat", "dan"}
+	tr := &Tournament{Format: formatRoundRobin, Players: players, Rounds: roundRobin(players)}
+	results := map[[2]string]string{
+		{"ann", "bob"}: "ann",
+		{"bob", "cat"}: "bob",
+		{"ann", "cat"}: "cat",
+		{"ann", "dan"}: "ann",
+		{"bob", "dan"}: "bob",
+		{"cat", "dan"}: "",
+	}
+	for _, round := range tr.Rounds {
+		for _, p := range round {
+			winner, ok := results[[2]string{min(p.Player1, p.Player2), max(p.Player1, p.Player2)}]
+			if !ok {
+				t.Fatalf("no result for %s against %s", p.Player1, p.Player2)
+			}
+			switch winner {
+			case p.Player1:
+				p.Result = resultPlayer1
+			case p.Player2:
+				p.Result = resultPlayer2
+			default:
+				p.Result = resultDraw
+			}
+		}
+	}
+
+	want := []game.Standing{
+		{Rank: 1, Username: "ann", Points: 2, TieBreak: 2.5, Wins: 2, Losses: 1},
+		{Rank: 2, Username: "bob", Points: 2, TieBreak: 2, Wins: 2, Losses: 1},
+		{Rank: 3, Username: "cat", Points: 1.5, TieBreak: 2.25, Wins: 1, Losses: 1, Draws: 1},
+		{Rank: 4, Username: "dan", Points: 0.5, TieBreak: 0.75, Losses: 2, Draws: 1},
+	}
+	got := tr.Standings()
+	if len(got) != len(want) {
+		t.Fatalf("%d rows", len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("row %d is %+v, want %+v", i+1, got[i], want[i])
+		}
+	}
+}
+
+// startTournament makes a tournament of names in that order of rating,
+// best first, and starts it
+func startTournament(t *testing.T, format string, names ...string) *Tournament {
+	t.Helper()
+	testServer(t, names...)
+	for i, name := range names {
+		accounts.accounts[name].Rating = float64(2000 - 100*i)
+	}
+	tr, err := createTournament(names[len(names)-1], format, game.Classic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names[:len(names)-1] {
+		if err := tr.join(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tr.start(names[0]); err == nil {
+		t.Fatal("somebody else started the tournament")
+	}
+	if err := tr.start(tr.Creator); err != nil {
+		t.Fatal(err)
+	}
+	for i, name := range names {
+		if tr.Players[i] != name {
+			t.Fatalf("seeds %v, want %v", tr.Players, names)
+		}
+	}
+	return tr
+}
+
+// sit puts both players of p in its room
+func sit(t *testing.T, p *Pairing) *Room {
+	t.Helper()
+	r := p.room
+	if r == nil {
+		t.Fatalf("%s against %s has no room", p.Player1, p.Player2)
+	}
+	for _, name := range r.reserved {
+		if err := r.seat(newTestClient(name).client); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+func TestKnockout(t *testing.T) {
+	tr := startTournament(t, formatElimination, "ann", "bob", "cat")
+
+	// ann has a bye, bob and cat play
+	first := tr.current()
+	if first[0].Player1 != "ann" || first[0].Result != resultPlayer1 || first[0].room != nil {
+		t.Fatalf("ann's bye is %+v", first[0])
+	}
+	r := sit(t, first[1])
+	play(t, r, player2Wins...)
+	if first[1].Result != resultPlayer2 || first[1].room != nil {
+		t.Fatalf("bob against cat is %+v", first[1])
+	}
+
+	// the final
+	if len(tr.Rounds) != 2 || len(tr.current()) != 1 {
+		t.Fatalf("%d rounds", len(tr.Rounds))
+	}
+	final := tr.current()[0]
+	if final.Player1 != "ann" || final.Player2 != "cat" {
+		t.Fatalf("the final is %s against %s", final.Player1, final.Player2)
+	}
+	play(t, sit(t, final), player1Wins...)
+	if tr.Status != statusFinished || tr.Winner != "ann" {
+		t.Errorf("%s, won by %q", tr.Status, tr.Winner)
+	}
+	standings := tr.Standings()
+	if standings[0].Username != "ann" || standings[0].Status != "champion" {
+		t.Errorf("standings %+v", standings)
+	}
+	if len(rooms) != 2 {
+		// nobody left, the rooms stay for the players to see the end
+		t.Errorf("%d rooms", len(rooms))
+	}
+}
+
+// a drawn knockout game is replayed without anybody asking, and when the
+// replays run out the better seed goes through
+func TestKnockoutReplays(t *testing.T) {
+	tr := startTournament(t, formatElimination, "ann", "bob")
+	p := tr.current()[0]
+	r := sit(t, p)
+	ann := testClient{r.players[0]}
+	for i := 0; i <= maxReplays; i++ {
+		if p.Result != resultPending || r.checkWin() != "" || len(r.moves) != 0 {
+			t.Fatalf("after %d draws result %d, the board %q with %d moves", i, p.Result, r.checkWin(), len(r.moves))
+		}
+		ann.sent(t, game.TypeUpdate)
+		play(t, r, drawn...)
+		if p.Replays != i+1 {
+			t.Fatalf("%d replays after %d draws", p.Replays, i+1)
+		}
+		// they get to see the draw before the board is cleared
+		var last game.Update
+		got := ann.sent(t, game.TypeUpdate)
+		if i < maxReplays && (len(got) < 2 || got[len(got)-2].Open(&last) != nil || last.Winner != "CAT") {
+			t.Fatalf("no drawn board in %d updates", len(got))
+		}
+	}
+	if p.Result != resultPlayer1 || tr.Winner != "ann" {
+		t.Errorf("result %d, won by %q", p.Result, tr.Winner)
+	}
+	// that's it, no more rematches
+	r.rematch()
+	if r.checkWin() == "" {
+		t.Error("the game started again")
+	}
+}
+
+func TestRoundRobinTournament(t *testing.T) {
+	tr := startTournament(t, formatRoundRobin, "ann", "bob", "cat")
+	// cat wins every game it plays first and loses the rest
+	for len(tr.Rounds) <= 3 && tr.Status == statusRunning {
+		round := len(tr.Rounds)
+		for _, p := range tr.current() {
+			if p.Player2 == "" {
+				continue
+			}
+			moves := player2Wins
+			if p.Player1 == "cat" {
+				moves = player1Wins
+			}
+			play(t, sit(t, p), moves...)
+		}
+		if len(tr.Rounds) == round && tr.Status == statusRunning {
+			t.Fatalf("round %d didn't end", round)
+		}
+	}
+	if tr.Status != statusFinished || len(tr.Rounds) != 3 {
+		t.Fatalf("%s after %d rounds", tr.Status, len(tr.Rounds))
+	}
+	games := 0
+	for _, s := range tr.Standings() {
+		games += s.Wins + s.Losses + s.Draws
+	}
+	if games != 6 {
+		t.Errorf("%d results, want 6 for 3 games", games)
+	}
+	if tr.Winner != tr.Standings()[0].Username {
+		t.Errorf("won by %s, top of the standings is %s", tr.Winner, tr.Standings()[0].Username)
+	}
+}
+
+// leaving a game that counts loses it
+func TestForfeit(t *testing.T) {
+	testServer(t, "ann", "bob")
+	r := newRoom(game.Classic)
+	ann, bob := newTestClient("ann"), newTestClient("bob")
+	for _, c := range []testClient{ann, bob} {
+		if err := r.seat(c.client); err != nil {
+			t.Fatal(err)
+		}
+	}
+	play(t, r, "b2")
+	r.leave(ann.client)
+	if r.checkWin() != "Player 2" {
+		t.Errorf("the game is %q after ann left", r.checkWin())
+	}
+	if a := accounts.accounts["ann"]; a.Losses != 1 {
+		t.Errorf("ann has %d losses", a.Losses)
+	}
+
+	// once it's over leaving doesn't change anything
+	r.leave(bob.client)
+	if b := accounts.accounts["bob"]; b.Wins != 1 || b.Losses != 0 {
+		t.Errorf("bob %d-%d", b.Wins, b.Losses)
+	}
+
+	// nor does leaving a game with nobody to play
+	alone := newRoom(game.Classic)
+	if err := alone.seat(ann.client); err != nil {
+		t.Fatal(err)
+	}
+	alone.leave(ann.client)
+	if a := accounts.accounts["ann"]; a.Games() != 1 {
+		t.Errorf("ann has %d games", a.Games())
+	}
+}
+
+// whoever is left in a finished quick match gets the next player to come
+// along, with a new game
+func TestQuickMatchAfterGame(t *testing.T) {
+	testServer(t, "ann", "bob", "cat")
+	ann, bob, cat := newTestClient("ann"), newTestClient("bob"), newTestClient("cat")
+	r := quickMatch(game.Classic)
+	for _, c := range []testClient{ann, bob} {
+		if err := r.seat(c.client); err != nil {
+			t.Fatal(err)
+		}
+	}
+	play(t, r, player1Wins...)
+	r.leave(bob.client)
+
+	if got := quickMatch(game.Classic); got != r {
+		t.Fatalf("cat got room %d, ann is waiting in %d", got.ID, r.ID)
+	}
+	if err := r.seat(cat.client); err != nil {
+		t.Fatal(err)
+	}
+	if r.checkWin() != "" || len(r.moves) != 0 {
+		t.Errorf("the game is %q with %d moves", r.checkWin(), len(r.moves))
+	}
+	if r.players[0] != ann.client || r.players[1] != cat.client {
+		t.Errorf("seats %v", r.names())
+	}
+}
+
+func TestTournamentForfeit(t *testing.T) {
+	tr := startTournament(t, formatElimination, "ann", "bob")
+	p := tr.current()[0]
+	r := sit(t, p)
+	play(t, r, "a1")
+	r.leave(r.players[0])
+	if p.Result != resultPlayer2 || tr.Winner != "bob" {
+		t.Errorf("result %d, won by %q", p.Result, tr.Winner)
+	}
+}
+
+func TestNoShow(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		here   []int // seats somebody sat down in
+		want   int
+	}{
+		{"player 2 missing", formatElimination, []int{1}, resultPlayer1},
+		{"player 1 missing", formatRoundRobin, []int{2}, resultPlayer2},
+		{"nobody, knockout", formatElimination, nil, resultPlayer1},
+		{"nobody, round robin", formatRoundRobin, nil, resultDraw},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := startTournament(t, tt.format, "ann", "bob")
+			p := tr.current()[0]
+			r := p.room
+			if r.noShow == nil {
+				t.Fatal("no clock waiting for the players")
+			}
+			for _, seat := range tt.here {
+				if err := r.seat(newTestClient(r.reserved[seat-1]).client); err != nil {
+					t.Fatal(err)
+				}
+			}
+			r.noShowTimeout()
+			if p.Result != tt.want {
+				t.Errorf("result %d, want %d", p.Result, tt.want)
+			}
+		})
+	}
+}
+
+// leaving the replay of a drawn knockout game loses the match, same as
+// leaving any other
+func TestLeaveReplay(t *testing.T) {
+	tr := startTournament(t, formatElimination, "ann", "bob")
+	p := tr.current()[0]
+	r := sit(t, p)
+	if r.noShow != nil {
+		t.Error("the clock is still going with both players there")
+	}
+	play(t, r, drawn...)
+	if p.Result != resultPending || r.checkWin() != "" {
+		t.Fatalf("result %d, the replay hasn't started: %q", p.Result, r.checkWin())
+	}
+	r.leave(r.players[0])
+	if p.Result != resultPlayer2 || tr.Winner != "bob" {
+		t.Errorf("result %d, won by %q", p.Result, tr.Winner)
+	}
+}
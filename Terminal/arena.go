@@ -0,0 +1,347 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"tictactoe/game"
+)
+
+// an arenaPlayer plays arena games, it's an engine (see engine.go) or one
+// of the built in players
+type arenaPlayer interface {
+	NewGame(rules game.Settings, seat int) error
+	Move(rules game.Settings, state game.State, limit time.Duration) (game.Input, error)
+	Result(winner string) error
+}
+
+// builtin is a player that lives in here rather than in its own program.
+// It doesn't watch the clock, the search is kept small enough instead.
+type builtin struct {
+	pick func(state game.State) (game.Input, bool)
+}
+
+func (b builtin) NewGame(game.Settings, int) error { return nil }
+func (b builtin) Result(string) error              { return nil }
+
+func (b builtin) Move(rules game.Settings, state game.State, limit time.Duration) (game.Input, error) {
+	in, ok := b.pick(state)
+	if !ok {
+		return in, errors.New("no move to make")
+	}
+	return in, nil
+}
+
+// entrant is one player in the arena and how it's done
+type entrant struct {
+	name   string
+	player arenaPlayer
+	rating float64
+
+	first, second [3]int // won, drawn and lost going first and second
+	forfeits      int
+	moves         int
+	thinking      time.Duration
+}
+
+// record is won, drawn and lost in every game
+func (e *entrant) record() [3]int {
+	return [3]int{e.first[0] + e.second[0], e.first[1] + e.second[1], e.first[2] + e.second[2]}
+}
+
+// playerList is -player, which can be given more than once
+type playerList []string
+
+func (l *playerList) String() string { return strings.Join(*l, ",") }
+
+func (l *playerList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// the most forfeits the report lists one by one
+const arenaForfeitLines = 10
+
+// runArena plays every player against every other one on this machine, no
+// server needed. Each pair plays -games games, taking turns going first,
+// and the report has the results and an Elo rating for each of them.
+func runArena(args []string) error {
+	fs := flag.NewFlagSet("arena", flag.ExitOnError)
+	var players playerList
+	fs.Var(&players, "player", "a player: random, minimax, minimax:N to look N moves ahead, or an engine to run like \"python3 mybot.py\". Give it once for each player")
+	board := fs.String("board", "1", "board to play, a number or a variant name (-board list shows them)")
+	variants := fs.String("variants", "", "folder of house rule variants to load, like the server's")
+	games := fs.Int("games", 100, "games each pair of players plays")
+	moveTime := fs.Duration("movetime", time.Second, "how long engines get for a move")
+	report := fs.String("report", "", "also write the report to this file")
+	bookFile := fs.String("book", "", "opening book for minimax to play from, see tictactoe solve")
+	fs.Parse(args)
+
+	var customs []game.CustomRules
+	if *variants != "" {
+		var err error
+		if customs, err = game.LoadVariants(*variants); err != nil {
+			return err
+		}
+	}
+	rules, err := pickBoard(*board, customs)
+	if err != nil {
+		return err
+	}
+	if rules.Seats() != 2 {
+		return fmt.Errorf("the arena only plays boards for two, %s has %d seats", rules.Name(), rules.Seats())
+	}
+	if len(players) == 0 {
+		players = playerList{"minimax", "random"}
+	}
+	if len(players) < 2 {
+		return errors.New("the arena needs at least two players")
+	}
+	if *games < 1 {
+		return errors.New("-games has to be at least 1")
+	}
+	var book *game.Book
+	if *bookFile != "" {
+		if book, err = game.LoadBook(*bookFile); err != nil {
+			return err
+		}
+	}
+
+	var entrants []*entrant
+	defer func() {
+		for _, e := range entrants {
+			if engine, ok := e.player.(*engine); ok {
+				engine.Close()
+			}
+		}
+	}()
+	names := map[string]int{}
+	for _, spec := range players {
+		e, err := newEntrant(spec, rules, book)
+		if err != nil {
+			return fmt.Errorf("%s: %v", spec, err)
+		}
+		// two of the same engine still need telling apart
+		if names[e.name]++; names[e.name] > 1 {
+			e.name = fmt.Sprintf("%s#%d", e.name, names[e.name])
+		}
+		entrants = append(entrants, e)
+	}
+
+	start := time.Now()
+	// results[i][j] is how i did against j, won, drawn and lost
+	results := make([][][3]int, len(entrants))
+	for i := range entrants {
+		results[i] = make([][3]int, len(entrants))
+	}
+	var forfeits []string
+	for i := range entrants {
+		for j := i + 1; j < len(entrants); j++ {
+			fmt.Fprintf(os.Stderr, "%s vs %s...\n", entrants[i].name, entrants[j].name)
+			for n := 0; n < *games; n++ {
+				seats := [2]int{i, j}
+				if n%2 == 1 {
+					seats = [2]int{j, i}
+				}
+				first, second := entrants[seats[0]], entrants[seats[1]]
+				winner, reason := playArena(rules, [2]*entrant{first, second}, *moveTime)
+				if reason != "" {
+					forfeits = append(forfeits, fmt.Sprintf("%s vs %s, game %d: %s", first.name, second.name, n+1, reason))
+				}
+
+				// 0 is a win for whoever went first, 1 a draw and 2 a loss
+				k := 1
+				switch winner {
+				case "Player 1":
+					k = 0
+				case "Player 2":
+					k = 2
+				}
+				first.first[k]++
+				second.second[2-k]++
+				results[seats[0]][seats[1]][k]++
+				results[seats[1]][seats[0]][2-k]++
+			}
+		}
+	}
+	for i, r := range ratings(results) {
+		entrants[i].rating = r
+	}
+
+	out := io.Writer(os.Stdout)
+	if *report != "" {
+		f, err := os.Create(*report)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = io.MultiWriter(os.Stdout, f)
+	}
+	writeReport(out, rules, entrants, results, forfeits, *games, time.Since(start))
+	return nil
+}
+
+// newEntrant makes the player -player asked for, minimax plays from book
+// when there is one (minimax:N is meant to miss things, so it doesn't)
+func newEntrant(spec string, rules game.Settings, book *game.Book) (*entrant, error) {
+	switch {
+	case spec == "random":
+		return &entrant{name: spec, player: builtin{game.RandomMove}}, nil
+	case spec == "minimax" || strings.HasPrefix(spec, "minimax:"):
+		depth := 0
+		if text, ok := strings.CutPrefix(spec, "minimax:"); ok {
+			var err error
+			if depth, err = strconv.Atoi(text); err != nil || depth < 1 {
+				return nil, errors.New("minimax:N needs N to be how many moves to look ahead")
+			}
+		}
+		search := game.NewSearch(rules, depth)
+		if book != nil && depth == 0 {
+			if err := search.UseBook(book); err != nil {
+				return nil, err
+			}
+		}
+		return &entrant{name: spec, player: builtin{search.Best}}, nil
+	}
+	e, err := startEngine(spec)
+	if err != nil {
+		return nil, err
+	}
+	return &entrant{name: e.name, player: e}, nil
+}
+
+// playArena plays one game, seats[0] going first. A player that can't come
+// up with a move, or can't even start the game, loses. reason says why.
+func playArena(rules game.Settings, seats [2]*entrant, limit time.Duration) (winner, reason string) {
+	state, err := rules.Start()
+	if err != nil {
+		return "CAT", err.Error()
+	}
+	// only the ones that got the game started hear how it went
+	started := 0
+	defer func() {
+		for _, e := range seats[:started] {
+			e.player.Result(winner)
+		}
+	}()
+	for i, e := range seats {
+		if err := e.player.NewGame(rules, i+1); err != nil {
+			e.forfeits++
+			return fmt.Sprintf("Player %d", 3-(i+1)), fmt.Sprintf("%s forfeits, %v", e.name, err)
+		}
+		started++
+	}
+
+	for state.Outcome() == "" {
+		seat := state.ToMove()
+		e := seats[seat-1]
+		start := time.Now()
+		in, err := e.player.Move(rules, state, limit)
+		e.thinking += time.Since(start)
+		e.moves++
+		if err == nil {
+			in.Player = seat
+			err = state.Apply(in)
+		}
+		if err != nil {
+			e.forfeits++
+			return fmt.Sprintf("Player %d", 3-seat), fmt.Sprintf("%s forfeits, %v", e.name, err)
+		}
+	}
+	return state.Outcome(), ""
+}
+
+// ratings works out everyone's Elo from how they did against each other.
+// Everybody also gets one made up draw against a 1500 player, or someone
+// who won every game would go up forever.
+func ratings(results [][][3]int) []float64 {
+	r := make([]float64, len(results))
+	for i := range r {
+		r[i] = 1500
+	}
+	expect := func(a, b float64) float64 {
+		return 1 / (1 + math.Pow(10, (b-a)/400))
+	}
+	for pass := 0; pass < 200; pass++ {
+		for i := range r {
+			p := expect(r[i], 1500)
+			score, expected, slope := 0.5, p, p*(1-p)
+			for j := range r {
+				res := results[i][j]
+				games := float64(res[0] + res[1] + res[2])
+				p := expect(r[i], r[j])
+				score += float64(res[0]) + float64(res[1])/2
+				expected += games * p
+				slope += games * p * (1 - p)
+			}
+			step := (score - expected) / (slope * math.Ln10 / 400)
+			r[i] += max(-400, min(400, step))
+		}
+	}
+	return r
+}
+
+func writeReport(w io.Writer, rules game.Settings, entrants []*entrant, results [][][3]int, forfeits []string, games int, took time.Duration) {
+	total := 0
+	for _, e := range entrants {
+		r := e.record()
+		total += r[0] + r[1] + r[2]
+	}
+	fmt.Fprintf(w, "Arena, %s, %d games for each pair, %d games in %s\n\n", rules.Name(), games, total/2, took.Round(time.Millisecond))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Player\tRating\tGames\tWon\tDrawn\tLost\tScore\tGoing first\tGoing second\tForfeits\tms a move\t")
+	for _, e := range ranked(entrants) {
+		r := e.record()
+		games := r[0] + r[1] + r[2]
+		score := (float64(r[0]) + float64(r[1])/2) / float64(games)
+		thinking := 0.0
+		if e.moves > 0 {
+			thinking = float64(e.thinking.Microseconds()) / 1000 / float64(e.moves)
+		}
+		fmt.Fprintf(tw, "%s\t%.0f\t%d\t%d\t%d\t%d\t%.1f%%\t%s\t%s\t%d\t%.2f\t\n", e.name, e.rating, games,
+			r[0], r[1], r[2], score*100, record(e.first), record(e.second), e.forfeits, thinking)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w, "\nEach pair, won-drawn-lost for the one on the left:")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, a := range entrants {
+		for j := i + 1; j < len(entrants); j++ {
+			fmt.Fprintf(tw, "  %s vs %s\t%s\n", a.name, entrants[j].name, record(results[i][j]))
+		}
+	}
+	tw.Flush()
+
+	if len(forfeits) > 0 {
+		fmt.Fprintf(w, "\n%d forfeits:\n", len(forfeits))
+		for i, line := range forfeits {
+			if i == arenaForfeitLines {
+				fmt.Fprintf(w, "  and %d more\n", len(forfeits)-i)
+				break
+			}
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+}
+
+// ranked is entrants from the best rating down
+func ranked(entrants []*entrant) []*entrant {
+	sorted := append([]*entrant{}, entrants...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].rating > sorted[j].rating })
+	return sorted
+}
+
+// record is won-drawn-lost
+func record(r [3]int) string {
+	return fmt.Sprintf("%d-%d-%d", r[0], r[1], r[2])
+}
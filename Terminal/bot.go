@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"tictactoe/game"
+)
+
+// runBot connects an engine (see engine.go) to the server as a bot
+// account. It plays -games games in the same room, asking for a rematch
+// after each one, and prints what happens like play does.
+func runBot(args []string) error {
+	fs := flag.NewFlagSet("bot", flag.ExitOnError)
+	server := fs.String("server", defaultServer, "host:port of the server")
+	user := fs.String("user", "", "the bot's account")
+	password := fs.String("password", "", "its password")
+	register := fs.Bool("register", false, "make the bot account before logging in")
+	command := fs.String("engine", "", "the engine to run, like \"python3 mybot.py\"")
+	board := fs.String("board", "1", "board to play, a number or a variant name (-board list shows them)")
+	room := fs.Int("room", 0, "room to join, 0 finds an opponent")
+	games := fs.Int("games", 1, "how many games to play, 0 keeps going")
+	fs.Parse(args)
+
+	if *user == "" || *password == "" || *command == "" {
+		return errors.New("bot needs -user, -password and -engine")
+	}
+	e, err := startEngine(*command)
+	if err != nil {
+		return err
+	}
+	defer e.Close()
+	fmt.Println("engine", e.name)
+
+	c, err := dial(*server)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	customs, err := c.login(game.Credentials{Username: *user, Password: *password, Bot: true}, *register)
+	if err != nil {
+		return err
+	}
+	rules, err := pickBoard(*board, customs)
+	if err != nil {
+		return err
+	}
+	if err := c.send(game.TypeJoin, game.Join{Room: *room, Rules: rules}); err != nil {
+		return err
+	}
+
+	var (
+		seat    int
+		played  int
+		started bool   // the engine knows about this game
+		last    string // the position we last moved in
+	)
+	for {
+		env, err := c.recv()
+		if err != nil {
+			return err
+		}
+		switch env.Type {
+		case game.TypeWelcome:
+			var welcome game.Welcome
+			env.Open(&welcome)
+			seat = welcome.Player
+			fmt.Println("seat", seat)
+
+		case game.TypeError:
+			var e game.Error
+			env.Open(&e)
+			fmt.Println("error", e.Text)
+
+		case game.TypeUpdate:
+			var update game.Update
+			env.Open(&update)
+			state, err := update.Rules.Load(update.State)
+			if err != nil {
+				return err
+			}
+			if update.Winner != "" {
+				if !started {
+					break // a game that was over before we got here
+				}
+				fmt.Println("result", update.Winner)
+				e.Result(update.Winner)
+				started = false
+				last = ""
+				played++
+				if played == *games {
+					return nil
+				}
+				c.send(game.TypeRematch, nil)
+				break
+			}
+			if !started {
+				if err := e.NewGame(update.Rules, seat); err != nil {
+					return err
+				}
+				started = true
+			}
+			if update.ToMove != seat || string(update.State) == last {
+				break // somebody sitting down sends the same position again
+			}
+			last = string(update.State)
+			// everything else waits while the engine thinks, it's all
+			// still there after
+			in, err := e.Move(update.Rules, state, engineTime(update.MoveTime))
+			if err != nil {
+				fmt.Println("engine", err)
+				break // the server's clock runs out and we lose
+			}
+			in.Player = seat
+			if err := c.send(game.TypeMove, in); err != nil {
+				return err
+			}
+			fmt.Println("sent", game.FormatMove(update.Rules, in))
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"tictactoe/game"
+)
+
+// the raspberry pi, same as the ebiten client
+const defaultServer = "100.67.88.56:8080"
+
+// conn is a connection to the server
+type conn struct {
+	net.Conn
+	enc *json.Encoder
+	dec *json.Decoder
+}
+
+func dial(addr string) (*conn, error) {
+	c, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: c, enc: json.NewEncoder(c), dec: json.NewDecoder(c)}, nil
+}
+
+func (c *conn) send(typ string, v any) error {
+	return game.Send(c.enc, typ, v)
+}
+
+func (c *conn) recv() (game.Envelope, error) {
+	var env game.Envelope
+	err := c.dec.Decode(&env)
+	return env, err
+}
+
+// login logs in, or makes the account first with register. The server
+// sends the house rule variants straight after, those are registered so
+// their games can be loaded.
+func (c *conn) login(cred game.Credentials, register bool) ([]game.CustomRules, error) {
+	typ := game.TypeLogin
+	if register {
+		typ = game.TypeRegister
+	}
+	if err := c.send(typ, cred); err != nil {
+		return nil, err
+	}
+	for {
+		env, err := c.recv()
+		if err != nil {
+			return nil, err
+		}
+		switch env.Type {
+		case game.TypeAuth:
+			var res game.AuthResult
+			env.Open(&res)
+			if !res.OK {
+				return nil, fmt.Errorf("login failed: %s", res.Error)
+			}
+		case game.TypeVariants:
+			var list game.Variants
+			env.Open(&list)
+			var customs []game.CustomRules
+			for _, rules := range list.List {
+				if err := rules.Register(); err == nil {
+					customs = append(customs, rules)
+				}
+			}
+			return customs, nil
+		case game.TypeError:
+			var e game.Error
+			env.Open(&e)
+			return nil, fmt.Errorf("login failed: %s", e.Text)
+		}
+	}
+}
+
+// pickBoard finds the board for -board: a number from boardList, or the
+// name of a variant
+func pickBoard(name string, customs []game.CustomRules) (game.Settings, error) {
+	boards := append([]game.Settings{}, game.Presets...)
+	for _, rules := range customs {
+		boards = append(boards, rules.Settings())
+	}
+	if n, err := strconv.Atoi(name); err == nil && n >= 1 && n <= len(boards) {
+		return boards[n-1], nil
+	}
+	for _, s := range boards {
+		if s.Variant != "" && s.Variant == name {
+			return s, nil
+		}
+	}
+	return game.Settings{}, fmt.Errorf("no board %q, pick one of\n%s", name, boardList(boards))
+}
+
+func boardList(boards []game.Settings) string {
+	var lines []string
+	for i, s := range boards {
+		lines = append(lines, fmt.Sprintf("  %2d  %s", i+1, s.Name()))
+	}
+	return strings.Join(lines, "\n")
+}
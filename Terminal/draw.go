@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"tictactoe/game"
+)
+
+// terminal colours, reset ends all of them
+const (
+	reverse   = "\x1b[7m"
+	highlight = "\x1b[43m" // first cell of a spooky mark
+	green     = "\x1b[32m" // the winning line
+	reset     = "\x1b[0m"
+	clear     = "\x1b[H\x1b[2J"
+)
+
+// marks for players 3 and 4, the ebiten client draws squares for them
+var seatMarks = map[int]string{1: "X", 2: "O", 3: "△", 4: "□"}
+
+// draw redraws the whole screen. Lines end in \r\n since raw mode doesn't
+// go back to the start of the line by itself.
+func (t *tui) draw() {
+	var b strings.Builder
+	line := func(format string, args ...any) {
+		fmt.Fprintf(&b, format, args...)
+		b.WriteString("\r\n")
+	}
+
+	b.WriteString(clear)
+	if t.player == 0 {
+		line("%s, watching", t.rules.Name())
+	} else {
+		line("%s, you're %s (%s)", t.rules.Name(), seatMarks[t.player], t.name(t.player))
+	}
+	line("")
+
+	wins := t.winningCells()
+	for layer := 0; layer < t.board.Layers; layer++ {
+		if t.board.Layers > 1 {
+			line("Layer %d", layer+1)
+		}
+		t.drawLayer(line, layer, wins)
+		line("")
+	}
+	t.drawVariant(line)
+
+	switch {
+	case t.winner == "CAT":
+		line("Nobody wins. /rematch for another game")
+	case t.winner != "":
+		var seat int
+		fmt.Sscanf(t.winner, "Player %d", &seat)
+		line("%s wins! /rematch for another game", t.name(seat))
+	case t.state == nil:
+		line("Waiting for the game to start")
+	case t.toMove == t.player:
+		line("Your turn")
+	default:
+		line("%s's turn", t.name(t.toMove))
+	}
+
+	for _, m := range t.chat {
+		if m.From == "" {
+			line("  %s", m.Text)
+		} else {
+			line("  %s: %s", m.From, m.Text)
+		}
+	}
+	if t.status != "" {
+		line("%s", t.status)
+	}
+	if t.raw {
+		line("arrows move, Enter plays, or type a move like b2. Anything else is chat. /rematch /quit")
+	} else {
+		line("type a move like b2 and Enter. Anything else is chat. /rematch /quit")
+	}
+	b.WriteString("> " + t.input)
+	t.print(b.String())
+}
+
+// drawLayer draws one layer of the board in box characters with the
+// column letters on top and the row numbers down the side
+func (t *tui) drawLayer(line func(string, ...any), layer int, wins map[game.Pos]bool) {
+	header := "    "
+	for col := 0; col < t.board.Cols; col++ {
+		header += fmt.Sprintf(" %c  ", 'a'+col)
+	}
+	line("%s", header)
+
+	border := func(left, middle, right string) {
+		line("   %s%s%s", left, strings.Repeat("───"+middle, t.board.Cols-1), "───"+right)
+	}
+	border("┌", "┬", "┐")
+	for row := 0; row < t.board.Rows; row++ {
+		cells := fmt.Sprintf("%2d │", row+1)
+		for col := 0; col < t.board.Cols; col++ {
+			pos := game.Pos{Layer: layer, Row: row, Col: col}
+			cells += t.cell(pos, wins) + "│"
+		}
+		line("%s", cells)
+		if row < t.board.Rows-1 {
+			border("├", "┼", "┤")
+		}
+	}
+	border("└", "┴", "┘")
+}
+
+// cell is what's in one cell, three characters wide
+func (t *tui) cell(pos game.Pos, wins map[game.Pos]bool) string {
+	v := t.board.Get(pos)
+	text := "   "
+	switch {
+	case v < 0:
+		text = "░░░" // blocked
+	case v > 0 && t.rules.Variant == game.VariantNumbers:
+		text = fmt.Sprintf(" %d ", v)
+	case v > 0 && t.rules.Variant == game.VariantNotakto:
+		text = " X " // everybody's X
+	case v > 0:
+		text = " " + seatMarks[v] + " "
+	}
+	switch {
+	case t.raw && pos == t.cursor:
+		return reverse + text + reset
+	case t.spooky != nil && pos == *t.spooky:
+		return highlight + text + reset
+	case wins[pos]:
+		return green + text + reset
+	}
+	return text
+}
+
+// winningCells are the cells of the line that ended the game, if there's
+// one to show
+func (t *tui) winningCells() map[game.Pos]bool {
+	if t.winner == "" || t.winner == "CAT" || t.rules.K == 0 || t.rules.Variant == game.VariantUltimate {
+		return nil
+	}
+	board := t.board
+	if t.rules.Variant == game.VariantNotakto {
+		board = board.Marks()
+	}
+	line, ok := board.FindLine(t.rules.K)
+	if t.rules.Variant == game.VariantNumbers {
+		line, ok = game.SumLine(board)
+	}
+	if !ok {
+		return nil
+	}
+	wins := map[game.Pos]bool{}
+	for _, p := range line.Cells() {
+		wins[p] = true
+	}
+	return wins
+}
+
+// drawVariant is the extra bits some variants need under the board
+func (t *tui) drawVariant(line func(string, ...any)) {
+	switch g := t.state.(type) {
+	case *game.UltimateGame:
+		if next := g.Ultimate.Next; next != -1 {
+			row, col := game.UltimateCell(next, 0, 0)
+			line("Play in the small board starting at %s", game.FormatPos(game.Pos{Row: row, Col: col}))
+		} else {
+			line("Play in any small board")
+		}
+	case *game.QuantumGame:
+		for _, m := range g.Quantum.Marks {
+			line("  %s%d  %s-%s", seatMarks[m.Player], m.Move, game.FormatPos(m.Cells[0]), game.FormatPos(m.Cells[1]))
+		}
+		if p := g.Quantum.Pending; p != -1 {
+			m := g.Quantum.Marks[p]
+			line("%s picks where %s%d goes: %s or %s", t.name(t.toMove), seatMarks[m.Player], m.Move, game.FormatPos(m.Cells[0]), game.FormatPos(m.Cells[1]))
+		}
+	}
+	if t.player == 0 || t.winner != "" {
+		return
+	}
+	switch {
+	case t.rules.Variant == game.VariantNumbers:
+		var left []string
+		for _, n := range game.Numbers(t.board, t.player) {
+			left = append(left, fmt.Sprint(n))
+		}
+		line("Yours: %s, putting down %s (Tab changes)", strings.Join(left, " "), strings.TrimPrefix(t.symbolText(), "="))
+	case t.rules.PickSymbol():
+		line("Putting down %s (Tab changes)", strings.ToUpper(strings.TrimPrefix(t.symbolText(), "=")))
+	}
+}
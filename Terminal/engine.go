@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tictactoe/game"
+)
+
+// An engine is a bot program that plays through its stdin and stdout, one
+// line at a time, so it can be written in anything. > is what the engine
+// gets and < is what it answers:
+//
+//	> tictactoe 1                 the protocol version, once at the start
+//	< name MyBot                  optional
+//	< ready
+//	> newgame {"Rows":3,...}      the game.Settings of a new game as JSON
+//	> seat 1                      the seat it plays in that game
+//	> position 3 1 X.O/.X./...    turn, seat to move and the board, like
+//	                              "tictactoe play" prints them
+//	> state {...}                 the variant's whole state as JSON, for
+//	                              the ones the board doesn't cover
+//	> legal a1 b1 c2 ...          every move it can make
+//	> go 9000                     milliseconds it has to answer
+//	< move b1
+//	> result Player 1             the game's over, Player N or CAT
+//	> quit
+//
+// Moves are written like game/notation.go says. Every go gets one move,
+// even after the time is up, the answers are matched to the go's in order.
+// Anything else the engine prints (like "info thinking hard") is ignored.
+type engine struct {
+	name  string
+	cmd   *exec.Cmd
+	in    io.WriteCloser
+	lines chan string   // what it prints, closed when it quits
+	done  chan struct{} // closed by Close, stops whatever is reading lines
+	owed  int           // go's it hasn't answered yet
+}
+
+// how long an engine gets to say ready
+const engineStartTime = 10 * time.Second
+
+// startEngine runs command (split on spaces) and waits for it to be ready
+func startEngine(command string) (*engine, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, errors.New("no engine to run")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = os.Stderr // its own logging goes straight through
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	e := &engine{name: filepath.Base(args[0]), cmd: cmd, in: in, lines: make(chan string), done: make(chan struct{})}
+	go func() {
+		defer close(e.lines)
+		scanner := bufio.NewScanner(out)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			select {
+			case e.lines <- strings.TrimSpace(scanner.Text()):
+			case <-e.done:
+				return
+			}
+		}
+	}()
+
+	if err := e.send("tictactoe 1"); err != nil {
+		e.Close()
+		return nil, err
+	}
+	deadline := time.After(engineStartTime)
+	for {
+		select {
+		case line, ok := <-e.lines:
+			if !ok {
+				e.Close()
+				return nil, errors.New("the engine quit before it was ready")
+			}
+			if name, ok := strings.CutPrefix(line, "name "); ok {
+				e.name = name
+			}
+			if line == "ready" {
+				return e, nil
+			}
+		case <-deadline:
+			e.Close()
+			return nil, errors.New("the engine never said ready")
+		}
+	}
+}
+
+func (e *engine) send(format string, args ...any) error {
+	_, err := fmt.Fprintf(e.in, format+"\n", args...)
+	return err
+}
+
+// NewGame tells the engine a game is starting
+func (e *engine) NewGame(rules game.Settings, seat int) error {
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+	if err := e.send("newgame %s", data); err != nil {
+		return err
+	}
+	return e.send("seat %d", seat)
+}
+
+// Move asks the engine for a move and waits for it, up to limit
+func (e *engine) Move(rules game.Settings, state game.State, limit time.Duration) (game.Input, error) {
+	data, err := state.Save()
+	if err != nil {
+		return game.Input{}, err
+	}
+	var legal []string
+	for _, in := range state.Moves() {
+		legal = append(legal, game.FormatMove(rules, in))
+	}
+	e.send("position %d %d %s", state.CurrentTurn(), state.ToMove(), boardText(rules, state.Grid()))
+	e.send("state %s", data)
+	e.send("legal %s", strings.Join(legal, " "))
+	if err := e.send("go %d", limit.Milliseconds()); err != nil {
+		return game.Input{}, err
+	}
+	e.owed++
+
+	deadline := time.After(limit)
+	for {
+		select {
+		case line, ok := <-e.lines:
+			if !ok {
+				return game.Input{}, errors.New("the engine quit")
+			}
+			text, ok := strings.CutPrefix(line, "move ")
+			if !ok {
+				continue
+			}
+			e.owed--
+			if e.owed > 0 {
+				continue // an answer that came too late for an earlier position
+			}
+			in, err := game.ParseMove(rules, state, text)
+			if err != nil {
+				return in, fmt.Errorf("the engine played %s: %v", text, err)
+			}
+			return in, nil
+		case <-deadline:
+			return game.Input{}, errors.New("the engine ran out of time")
+		}
+	}
+}
+
+// Result tells the engine how the game ended
+func (e *engine) Result(winner string) error {
+	return e.send("result %s", winner)
+}
+
+// Close asks the engine to quit, and makes it if it won't
+func (e *engine) Close() {
+	close(e.done)
+	e.send("quit")
+	e.in.Close()
+	done := make(chan struct{})
+	go func() {
+		e.cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		e.cmd.Process.Kill()
+	}
+}
+
+// how long an engine gets when there's no clock
+const engineMoveTime = 10 * time.Second
+
+// engineTime is how long to give the engine when the server allows
+// milliseconds, leaving some for the trip to the server and back
+func engineTime(milliseconds int) time.Duration {
+	if milliseconds <= 0 {
+		return engineMoveTime
+	}
+	limit := time.Duration(milliseconds) * time.Millisecond
+	return max(limit*9/10, 100*time.Millisecond)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"unicode/utf8"
+)
+
+// key is one key press. Special keys have a name, everything else is the
+// rune typed.
+type key struct {
+	r    rune
+	name string
+}
+
+// names of the keys that aren't characters
+const (
+	keyUp        = "up"
+	keyDown      = "down"
+	keyLeft      = "left"
+	keyRight     = "right"
+	keyPageUp    = "pgup"
+	keyPageDown  = "pgdn"
+	keyEnter     = "enter"
+	keyBackspace = "backspace"
+	keyEscape    = "esc"
+	keyTab       = "tab"
+	keyQuit      = "ctrl-c"
+)
+
+// escape sequences terminals send for the special keys
+var sequences = map[string]string{
+	"\x1b[A":  keyUp,
+	"\x1b[B":  keyDown,
+	"\x1b[C":  keyRight,
+	"\x1b[D":  keyLeft,
+	"\x1bOA":  keyUp,
+	"\x1bOB":  keyDown,
+	"\x1bOC":  keyRight,
+	"\x1bOD":  keyLeft,
+	"\x1b[5~": keyPageUp,
+	"\x1b[6~": keyPageDown,
+}
+
+// readKeys sends every key typed on r to keys until r ends. In raw mode a
+// read gets one key (or one escape sequence) at a time, in line mode it
+// gets a whole line which comes out as its characters and then Enter.
+func readKeys(r io.Reader, keys chan<- key) {
+	defer close(keys)
+	in := bufio.NewReader(r)
+	buf := make([]byte, 64)
+	for {
+		n, err := in.Read(buf)
+		if err != nil {
+			return
+		}
+		data := buf[:n]
+		for len(data) > 0 {
+			k, size := nextKey(data)
+			keys <- k
+			data = data[size:]
+		}
+	}
+}
+
+// nextKey decodes the first key in data and how many bytes it took
+func nextKey(data []byte) (key, int) {
+	if data[0] == 0x1b {
+		for seq, name := range sequences {
+			if len(data) >= len(seq) && string(data[:len(seq)]) == seq {
+				return key{name: name}, len(seq)
+			}
+		}
+		return key{name: keyEscape}, 1
+	}
+	if len(data) > 1 && data[0] == '\r' && data[1] == '\n' {
+		return key{name: keyEnter}, 2 // windows line ending in line mode
+	}
+	switch data[0] {
+	case '\r', '\n':
+		return key{name: keyEnter}, 1
+	case 0x7f, 0x08:
+		return key{name: keyBackspace}, 1
+	case '\t':
+		return key{name: keyTab}, 1
+	case 0x03:
+		return key{name: keyQuit}, 1
+	}
+	r, size := utf8.DecodeRune(data)
+	return key{r: r}, size
+}
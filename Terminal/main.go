@@ -0,0 +1,49 @@
+// tictactoe is the client for terminals, for when there's no screen to run
+// the ebiten one on (like over ssh on the raspberry pi):
+//
+//	tictactoe tui    the game with the board drawn in text
+//	tictactoe play   one game with the moves given up front or on stdin,
+//	                 for scripts and bots
+//	tictactoe bot    connects an engine program to the server as a bot
+//	tictactoe arena  plays bots against each other without a server and
+//	                 reports how they did
+//	tictactoe solve  works out every game on a small board and saves it
+//	                 as an opening book
+//
+// Every command takes -h for its flags.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "tui":
+		err = runTUI(os.Args[2:])
+	case "play":
+		err = runPlay(os.Args[2:])
+	case "bot":
+		err = runBot(os.Args[2:])
+	case "arena":
+		err = runArena(os.Args[2:])
+	case "solve":
+		err = runSolve(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: tictactoe tui|play|bot|arena|solve [flags]")
+	os.Exit(2)
+}
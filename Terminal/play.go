@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"tictactoe/game"
+)
+
+// runPlay plays one game without any screen, for scripts and bots. The
+// moves come from -moves or one per line on stdin, and what happens is
+// printed one line at a time:
+//
+//	seat 1                              which seat we got, 0 is watching
+//	update 3 2 X.O/.X./...              turn, who's to move and the board
+//	sent b2                             a move we played
+//	chat bobby: hi
+//	error that cell is taken            the server didn't like something
+//	result Player 1                     the game's over, Player N or CAT
+//
+// It exits 0 once the game's over, and 1 if anything goes wrong before
+// that (or the result isn't -expect).
+func runPlay(args []string) error {
+	fs := flag.NewFlagSet("play", flag.ExitOnError)
+	server := fs.String("server", defaultServer, "host:port of the server")
+	user := fs.String("user", "", "account name")
+	password := fs.String("password", "", "password")
+	register := fs.Bool("register", false, "make the account before logging in")
+	board := fs.String("board", "1", "board to play, a number or a variant name (-board list shows them)")
+	room := fs.Int("room", 0, "room to join, 0 finds an opponent")
+	moves := fs.String("moves", "", "moves to play in order, like a1,b2,c3. Read from stdin if there are none")
+	timeout := fs.Duration("timeout", 5*time.Minute, "give up if the game isn't over by then")
+	expect := fs.String("expect", "", "exit 1 unless the result is this, like \"Player 1\" or CAT")
+	fs.Parse(args)
+
+	if *user == "" || *password == "" {
+		return errors.New("play needs -user and -password")
+	}
+	c, err := dial(*server)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	customs, err := c.login(game.Credentials{Username: *user, Password: *password}, *register)
+	if err != nil {
+		return err
+	}
+	rules, err := pickBoard(*board, customs)
+	if err != nil {
+		return err
+	}
+	if err := c.send(game.TypeJoin, game.Join{Room: *room, Rules: rules}); err != nil {
+		return err
+	}
+
+	events := make(chan game.Envelope)
+	go func() {
+		defer close(events)
+		for {
+			env, err := c.recv()
+			if err != nil {
+				return
+			}
+			events <- env
+		}
+	}()
+
+	// moves waiting for our turn. With -moves they're all there from the
+	// start, otherwise they come in from stdin.
+	var queue []string
+	var lines chan string
+	fromStdin := *moves == ""
+	if !fromStdin {
+		queue = strings.Split(*moves, ",")
+	} else {
+		lines = make(chan string)
+		go func() {
+			defer close(lines)
+			scanner := bufio.NewScanner(os.Stdin)
+			for scanner.Scan() {
+				if line := strings.TrimSpace(scanner.Text()); line != "" {
+					lines <- line
+				}
+			}
+		}()
+	}
+
+	var (
+		seat    int
+		update  game.Update
+		state   game.State
+		waiting bool // sent a move, the server hasn't answered yet
+	)
+	deadline := time.After(*timeout)
+	for {
+		select {
+		case env, ok := <-events:
+			if !ok {
+				return errors.New("the server closed the connection")
+			}
+			switch env.Type {
+			case game.TypeWelcome:
+				var welcome game.Welcome
+				env.Open(&welcome)
+				seat = welcome.Player
+				fmt.Println("seat", seat)
+
+			case game.TypeUpdate:
+				env.Open(&update)
+				waiting = false
+				if state, err = update.Rules.Load(update.State); err != nil {
+					return err
+				}
+				fmt.Println("update", update.Turn, update.ToMove, boardText(update.Rules, update.Board))
+				if update.Winner != "" {
+					fmt.Println("result", update.Winner)
+					if *expect != "" && update.Winner != *expect {
+						return fmt.Errorf("expected %s", *expect)
+					}
+					return nil
+				}
+
+			case game.TypeChat:
+				var m game.Message
+				env.Open(&m)
+				if m.From == "" {
+					fmt.Println("chat", m.Text)
+				} else {
+					fmt.Printf("chat %s: %s\n", m.From, m.Text)
+				}
+
+			case game.TypeError:
+				var e game.Error
+				env.Open(&e)
+				fmt.Println("error", e.Text)
+				if !fromStdin {
+					return errors.New(e.Text) // a script's moves are meant to work
+				}
+				waiting = false // a bot gets to try again
+			}
+
+		case line, ok := <-lines:
+			if !ok {
+				lines = nil // that's all of them
+				break
+			}
+			queue = append(queue, line)
+
+		case <-deadline:
+			return errors.New("timed out")
+		}
+
+		if state == nil || seat == 0 || update.ToMove != seat || waiting {
+			continue
+		}
+		if len(queue) == 0 {
+			if lines == nil {
+				return errors.New("ran out of moves")
+			}
+			continue
+		}
+		text := strings.TrimSpace(queue[0])
+		queue = queue[1:]
+		in, err := game.ParseMove(update.Rules, state, text)
+		if err != nil {
+			fmt.Println("error", err)
+			if !fromStdin {
+				return err
+			}
+			continue
+		}
+		in.Player = seat
+		if err := c.send(game.TypeMove, in); err != nil {
+			return err
+		}
+		fmt.Println("sent", text)
+		waiting = true
+	}
+}
+
+// boardText writes the board on one line, rows split by / and layers by |.
+// Empty cells are . and blocked ones #.
+func boardText(rules game.Settings, b game.Board) string {
+	var layers []string
+	for layer := 0; layer < b.Layers; layer++ {
+		var rows []string
+		for row := 0; row < b.Rows; row++ {
+			var cells strings.Builder
+			for col := 0; col < b.Cols; col++ {
+				v := b.Get(game.Pos{Layer: layer, Row: row, Col: col})
+				switch {
+				case v == 0:
+					cells.WriteString(".")
+				case v < 0:
+					cells.WriteString("#")
+				case rules.Variant == game.VariantNumbers:
+					fmt.Fprint(&cells, v)
+				default:
+					cells.WriteString(seatMarks[v])
+				}
+			}
+			rows = append(rows, cells.String())
+		}
+		layers = append(layers, strings.Join(rows, "/"))
+	}
+	return strings.Join(layers, "|")
+}
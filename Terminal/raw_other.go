@@ -0,0 +1,11 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+// makeRaw only knows linux terminals, everywhere else the tui reads whole
+// lines so moves have to be typed
+func makeRaw(fd int) (restore func(), err error) {
+	return nil, errors.New("raw terminal mode only works on linux")
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"tictactoe/game"
+)
+
+// runSolve goes through every game on a small board, prints what it found
+// and saves the opening book. The client's bot and hints, and the arena's
+// minimax with -book, play straight out of it.
+func runSolve(args []string) error {
+	fs := flag.NewFlagSet("solve", flag.ExitOnError)
+	board := fs.String("board", "1", "board to solve, a number or a variant name (-board list shows them)")
+	out := fs.String("out", "book.json", "file to save the book in, \"\" to only print the stats")
+	fs.Parse(args)
+
+	rules, err := pickBoard(*board, nil)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	book, err := game.BuildBook(rules)
+	if err != nil {
+		return err
+	}
+	state, err := rules.Start()
+	if err != nil {
+		return err
+	}
+	first, _ := book.Lookup(state)
+	fmt.Printf("%s, solved in %s\n", rules.Name(), time.Since(start).Round(time.Millisecond))
+	fmt.Printf("from the start it's a %s for player 1\n", first)
+	fmt.Println(book.Stats())
+
+	if *out == "" {
+		return nil
+	}
+	if err := book.Save(*out); err != nil {
+		return err
+	}
+	fmt.Println("saved the book in", *out)
+	return nil
+}
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"tictactoe/game"
+)
+
+// how many chat lines fit under the board
+const tuiChatLines = 5
+
+// a typed line that's a move rather than a chat message, see
+// game/notation.go
+var moveText = regexp.MustCompile(`^(\d+:)?[a-s]\d*(-(\d+:)?[a-s]\d+)?(=\w+)?$`)
+
+// tui is the text client, the same game as the ebiten one drawn with box
+// characters
+type tui struct {
+	c   *conn
+	raw bool // keys come one at a time, otherwise only typed lines work
+
+	rules   game.Settings
+	board   game.Board
+	state   game.State // the game as the server last sent it, nil before that
+	player  int        // our seat, 0 when watching
+	turn    int
+	toMove  int
+	winner  string
+	names   []string
+	bots    []bool
+	chat    []game.Message
+	cursor  game.Pos
+	input   string // what's been typed since the last Enter
+	status  string // last error, or what to do next
+	symbol  int    // what Enter puts down in variants where you pick
+	spooky  *game.Pos
+	customs []game.CustomRules
+}
+
+func runTUI(args []string) error {
+	fs := flag.NewFlagSet("tui", flag.ExitOnError)
+	server := fs.String("server", defaultServer, "host:port of the server")
+	user := fs.String("user", "", "account name, asked for if it's empty")
+	password := fs.String("password", "", "password, asked for if it's empty")
+	register := fs.Bool("register", false, "make the account before logging in")
+	board := fs.String("board", "1", "board to play, a number or a variant name (-board list shows them)")
+	room := fs.Int("room", 0, "room to join, 0 finds an opponent")
+	watch := fs.Bool("watch", false, "watch -room instead of playing")
+	fs.Parse(args)
+
+	t := &tui{symbol: game.X}
+	if restore, err := makeRaw(int(os.Stdin.Fd())); err == nil {
+		t.raw = true
+		defer restore()
+	}
+	keys := make(chan key)
+	go readKeys(os.Stdin, keys)
+
+	var ok bool
+	if *user == "" {
+		if *user, ok = t.prompt(keys, "Username: ", false); !ok {
+			return nil
+		}
+	}
+	if *password == "" {
+		if *password, ok = t.prompt(keys, "Password: ", true); !ok {
+			return nil
+		}
+	}
+
+	c, err := dial(*server)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	t.c = c
+	if t.customs, err = c.login(game.Credentials{Username: *user, Password: *password}, *register); err != nil {
+		return err
+	}
+	rules, err := pickBoard(*board, t.customs)
+	if err != nil {
+		return err
+	}
+	if err := c.send(game.TypeJoin, game.Join{Room: *room, Rules: rules, Watch: *watch}); err != nil {
+		return err
+	}
+	t.setRules(rules)
+	t.status = "Waiting for the server..."
+
+	events := make(chan game.Envelope)
+	go func() {
+		defer close(events)
+		for {
+			env, err := c.recv()
+			if err != nil {
+				return
+			}
+			events <- env
+		}
+	}()
+
+	t.draw()
+	for {
+		select {
+		case k, ok := <-keys:
+			if !ok || !t.key(k) {
+				c.send(game.TypeLeave, nil)
+				t.print("\r\n")
+				return nil
+			}
+		case env, ok := <-events:
+			if !ok {
+				return errors.New("the server closed the connection")
+			}
+			t.handle(env)
+		}
+		t.draw()
+	}
+}
+
+// prompt asks for one line before the game starts. In raw mode we have to
+// echo it ourselves, which is how the password gets hidden. False means
+// ctrl-c.
+func (t *tui) prompt(keys <-chan key, label string, hide bool) (string, bool) {
+	t.print(label)
+	var line string
+	for k := range keys {
+		switch {
+		case k.name == keyQuit:
+			t.print("\r\n")
+			return "", false
+		case k.name == keyEnter:
+			t.print("\r\n")
+			return line, true
+		case k.name == keyBackspace && line != "":
+			line = line[:len(line)-1]
+			if t.raw {
+				t.print("\b \b")
+			}
+		case k.name == "" && k.r > ' ' && k.r < 127:
+			line += string(k.r)
+			if t.raw && hide {
+				t.print("*")
+			} else if t.raw {
+				t.print(string(k.r))
+			}
+		}
+	}
+	return "", false
+}
+
+// handle is one message from the server
+func (t *tui) handle(env game.Envelope) {
+	switch env.Type {
+	case game.TypeWelcome:
+		var welcome game.Welcome
+		env.Open(&welcome)
+		t.player = welcome.Player
+		t.names = welcome.Names
+		t.setRules(welcome.Rules)
+		t.chat = nil // the server sends the room's chat next
+		t.status = ""
+
+	case game.TypeUpdate:
+		var update game.Update
+		env.Open(&update)
+		if update.Rules != t.rules {
+			t.setRules(update.Rules)
+		}
+		t.board = update.Board
+		t.turn = update.Turn
+		t.toMove = update.ToMove
+		t.winner = update.Winner
+		t.player = update.Player
+		t.names = update.Names
+		t.bots = update.Bots
+		if state, err := update.Rules.Load(update.State); err == nil {
+			t.state = state
+		}
+		t.checkNumber()
+
+	case game.TypeVariants:
+		var list game.Variants
+		env.Open(&list)
+		for _, rules := range list.List {
+			if rules.Register() == nil {
+				t.customs = append(t.customs, rules)
+			}
+		}
+
+	case game.TypeChat:
+		var m game.Message
+		env.Open(&m)
+		t.chat = append(t.chat, m)
+		if len(t.chat) > tuiChatLines {
+			t.chat = t.chat[len(t.chat)-tuiChatLines:]
+		}
+
+	case game.TypeError:
+		var e game.Error
+		env.Open(&e)
+		t.status = e.Text
+	}
+}
+
+// setRules switches to the board of the room we're in
+func (t *tui) setRules(rules game.Settings) {
+	t.rules = rules
+	t.board = rules.NewBoard()
+	t.state = nil
+	t.cursor = game.Pos{}
+	t.spooky = nil
+	t.symbol = game.X
+	if rules.Variant == game.VariantNumbers {
+		t.symbol = 0 // checkNumber picks one once an update says what we have
+	}
+}
+
+// key handles one key press, false quits
+func (t *tui) key(k key) bool {
+	switch k.name {
+	case keyQuit:
+		return false
+	case keyUp:
+		t.cursor.Row = max(t.cursor.Row-1, 0)
+	case keyDown:
+		t.cursor.Row = min(t.cursor.Row+1, t.board.Rows-1)
+	case keyLeft:
+		t.cursor.Col = max(t.cursor.Col-1, 0)
+	case keyRight:
+		t.cursor.Col = min(t.cursor.Col+1, t.board.Cols-1)
+	case keyPageUp:
+		t.cursor.Layer = max(t.cursor.Layer-1, 0)
+	case keyPageDown:
+		t.cursor.Layer = min(t.cursor.Layer+1, t.board.Layers-1)
+	case keyTab:
+		t.nextSymbol()
+	case keyEscape:
+		t.input = ""
+		t.spooky = nil
+	case keyBackspace:
+		if t.input != "" {
+			t.input = t.input[:len(t.input)-1]
+		}
+	case keyEnter:
+		line := strings.TrimSpace(t.input)
+		t.input = ""
+		return t.enter(line)
+	default:
+		if k.r >= ' ' && len(t.input) < 100 {
+			t.input += string(k.r)
+		}
+	}
+	return true
+}
+
+// enter does a typed line: a command, a move or a chat message
+func (t *tui) enter(line string) bool {
+	lower := strings.ToLower(line)
+	switch {
+	case lower == "/quit":
+		return false
+	case lower == "/rematch":
+		if t.winner == "" || t.player == 0 {
+			t.status = "Rematches are for players once the game's over"
+			break
+		}
+		t.c.send(game.TypeRematch, nil)
+	case line == "":
+		if t.raw {
+			t.playCursor("")
+		}
+	case strings.HasPrefix(line, "="):
+		t.playCursor(lower)
+	case moveText.MatchString(lower):
+		t.play(lower)
+	default:
+		t.c.send(game.TypeChat, game.Message{Text: line})
+	}
+	return true
+}
+
+// playCursor plays the cell under the cursor, with the symbol we've got
+// picked unless the line gave one
+func (t *tui) playCursor(symbol string) {
+	text := game.FormatPos(t.cursor)
+	if q, ok := t.state.(*game.QuantumGame); ok && q.Quantum.Pending == -1 && t.free() > 1 {
+		// a spooky mark takes two cells, the first one waits in t.spooky
+		switch {
+		case t.spooky == nil:
+			from := t.cursor
+			t.spooky = &from
+			t.status = "Now the other cell of the spooky mark"
+			return
+		case *t.spooky == t.cursor:
+			t.spooky = nil
+			return
+		}
+		text = game.FormatPos(*t.spooky) + "-" + text
+		t.spooky = nil
+	}
+	if symbol == "" && t.rules.PickSymbol() {
+		symbol = t.symbolText()
+	}
+	t.play(text + symbol)
+}
+
+// play sends a move written like b2
+func (t *tui) play(text string) {
+	switch {
+	case t.player == 0:
+		t.status = "You're watching"
+		return
+	case t.state == nil:
+		t.status = "The game hasn't started yet"
+		return
+	case t.winner != "":
+		t.status = "The game's over, /rematch for another"
+		return
+	case t.toMove != t.player:
+		t.status = "It's not your turn"
+		return
+	}
+	in, err := game.ParseMove(t.rules, t.state, text)
+	if err != nil {
+		t.status = err.Error()
+		return
+	}
+	in.Player = t.player
+	t.status = ""
+	t.c.send(game.TypeMove, in)
+}
+
+// nextSymbol is Tab, it swaps X and O or goes to our next number
+func (t *tui) nextSymbol() {
+	switch {
+	case t.rules.Variant == game.VariantNumbers:
+		numbers := game.Numbers(t.board, t.player)
+		if len(numbers) == 0 {
+			return
+		}
+		next := numbers[0] // back to the smallest after the biggest
+		for _, n := range numbers {
+			if n > t.symbol {
+				next = n
+				break
+			}
+		}
+		t.symbol = next
+	case t.rules.PickSymbol():
+		t.symbol = 3 - t.symbol
+	}
+}
+
+// checkNumber moves us off a number that's been played, to the smallest
+// one we have left. Every update goes through it, so drawing and moving
+// can take t.symbol as it is.
+func (t *tui) checkNumber() {
+	if t.rules.Variant != game.VariantNumbers {
+		return
+	}
+	numbers := game.Numbers(t.board, t.player)
+	for _, n := range numbers {
+		if n == t.symbol {
+			return
+		}
+	}
+	t.symbol = 0
+	if len(numbers) > 0 {
+		t.symbol = numbers[0]
+	}
+}
+
+// symbolText is the symbol we've picked as the end of a move, like =o
+func (t *tui) symbolText() string {
+	if t.rules.Variant == game.VariantNumbers {
+		return fmt.Sprintf("=%d", t.symbol)
+	}
+	if t.symbol == game.O {
+		return "=o"
+	}
+	return "=x"
+}
+
+func (t *tui) free() int {
+	n := 0
+	for _, v := range t.board.Cells {
+		if v == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// name is the account in a seat, or Player N while it's empty
+func (t *tui) name(seat int) string {
+	if seat >= 1 && seat <= len(t.names) && t.names[seat-1] != "" {
+		if seat <= len(t.bots) && t.bots[seat-1] {
+			return t.names[seat-1] + " (bot)"
+		}
+		return t.names[seat-1]
+	}
+	return fmt.Sprintf("Player %d", seat)
+}
+
+func (t *tui) print(s string) {
+	os.Stdout.WriteString(s)
+}
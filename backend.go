@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+
+	"tictactoe/game"
+)
+
+// Backend is where the game on the screen is played: the server, or a game
+// on this computer. The UI sends it moves and shows whatever comes out of
+// Events, so it doesn't care which one it is.
+type Backend interface {
+	// SubmitMove plays a move for the player at the keyboard. Moves the
+	// rules don't allow come back as an error or a TypeError event.
+	SubmitMove(in game.Input) error
+	// RequestRematch asks for a new game once this one's over
+	RequestRematch() error
+	// Chat sends a message to the others in the game
+	Chat(text string) error
+	// Leave is done with the game, local games close Events
+	Leave() error
+	// Events has the same messages the server sends, TypeUpdate after
+	// every move and so on
+	Events() <-chan game.Envelope
+}
+
+// networkBackend is the connection to the server. Everything outside of a
+// game (logging in, the menu, tournaments) goes through Send.
+type networkBackend struct {
+	conn   net.Conn
+	enc    *json.Encoder
+	events chan game.Envelope
+}
+
+func newNetworkBackend(conn net.Conn) *networkBackend {
+	n := &networkBackend{
+		conn:   conn,
+		enc:    json.NewEncoder(conn),
+		events: make(chan game.Envelope, 16),
+	}
+	go n.read()
+	return n
+}
+
+// read passes on everything the server sends until the connection drops
+func (n *networkBackend) read() {
+	defer close(n.events)
+	dec := json.NewDecoder(n.conn)
+	for {
+		var env game.Envelope
+		if err := dec.Decode(&env); err != nil {
+			fmt.Println("Decode error:", err)
+			return
+		}
+		n.events <- env
+	}
+}
+
+// Send wraps v up in an envelope and sends it to the server
+func (n *networkBackend) Send(typ string, v any) error {
+	return game.Send(n.enc, typ, v)
+}
+
+func (n *networkBackend) SubmitMove(in game.Input) error {
+	return n.Send(game.TypeMove, in)
+}
+
+func (n *networkBackend) RequestRematch() error {
+	return n.Send(game.TypeRematch, nil)
+}
+
+func (n *networkBackend) Chat(text string) error {
+	return n.Send(game.TypeChat, game.Message{Text: text})
+}
+
+func (n *networkBackend) Leave() error {
+	return n.Send(game.TypeLeave, nil)
+}
+
+func (n *networkBackend) Events() <-chan game.Envelope {
+	return n.events
+}
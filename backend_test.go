@@ -0,0 +1,305 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"tictactoe/game"
+)
+
+// fakeBackend is a Backend the tests send events through by hand. It
+// remembers what the UI asked of it.
+type fakeBackend struct {
+	events    chan game.Envelope
+	moves     []game.Input
+	chats     []string
+	rematches int
+	left      bool
+	err       error // what SubmitMove says
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{events: make(chan game.Envelope, 16)}
+}
+
+func (f *fakeBackend) SubmitMove(in game.Input) error {
+	f.moves = append(f.moves, in)
+	return f.err
+}
+
+func (f *fakeBackend) RequestRematch() error {
+	f.rematches++
+	return nil
+}
+
+func (f *fakeBackend) Chat(text string) error {
+	f.chats = append(f.chats, text)
+	return nil
+}
+
+func (f *fakeBackend) Leave() error {
+	f.left = true
+	close(f.events)
+	return nil
+}
+
+func (f *fakeBackend) Events() <-chan game.Envelope {
+	return f.events
+}
+
+// send queues an event the way the server would send it
+func (f *fakeBackend) send(t *testing.T, typ string, v any) {
+	t.Helper()
+	env, err := game.Wrap(typ, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.events <- env
+}
+
+// update is the Update the server sends after moves on rules
+func update(t *testing.T, rules game.Settings, moves ...string) game.Update {
+	t.Helper()
+	state, err := rules.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range moves {
+		in, err := game.ParseMove(rules, state, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		in.Player = state.ToMove()
+		if err := state.Apply(in); err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+	}
+	data, err := state.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return game.Update{
+		Player: 1,
+		Rules:  rules,
+		Board:  state.Grid(),
+		Turn:   state.CurrentTurn(),
+		ToMove: state.ToMove(),
+		Winner: state.Outcome(),
+		Names:  []string{"ann", "bob"},
+		State:  data,
+	}
+}
+
+// playing is a Game in the middle of a game on f
+func playing(f *fakeBackend) *Game {
+	g := NewGame()
+	g.local = f
+	g.state = StatePlaying
+	return g
+}
+
+func TestWelcome(t *testing.T) {
+	f := newFakeBackend()
+	g := playing(f)
+	g.addChat(game.Message{Text: "from the last room"})
+	rules := game.Settings{Rows: 5, Cols: 5, K: 4}
+	f.send(t, game.TypeWelcome, game.Welcome{Player: 2, Names: []string{"ann", "bob"}, Rules: rules})
+	g.pump()
+
+	if g.player != 2 {
+		t.Errorf("player = %d, want 2", g.player)
+	}
+	if g.rules != rules {
+		t.Errorf("rules = %+v, want %+v", g.rules, rules)
+	}
+	if g.cellSize != boardPixels/5 {
+		t.Errorf("cellSize = %d, want %d", g.cellSize, boardPixels/5)
+	}
+	if g.name(1) != "ann" || g.name(2) != "bob" {
+		t.Errorf("names = %q", g.names)
+	}
+	if len(g.chat) != 0 {
+		t.Errorf("chat from the last room is still there: %v", g.chat)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  game.Settings
+		moves  []string
+		toMove int
+		winner string
+	}{
+		{"start", game.Classic, nil, 1, ""},
+		{"one move", game.Classic, []string{"b2"}, 2, ""},
+		{"x wins", game.Classic, []string{"a1", "a2", "b1", "b2", "c1"}, 2, "Player 1"},
+		{"bigger board", game.Settings{Rows: 4, Cols: 4, K: 4}, []string{"a1", "d4"}, 1, ""},
+		{"gravity", game.Settings{Rows: 6, Cols: 7, K: 4, Gravity: true}, []string{"d6", "d5"}, 1, ""},
+		{"three players", game.Settings{Rows: 6, Cols: 6, K: 4, Players: 3}, []string{"a1", "b1"}, 3, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeBackend()
+			g := playing(f)
+			want := update(t, tt.rules, tt.moves...)
+			f.send(t, game.TypeUpdate, want)
+			g.pump()
+
+			if g.rules != tt.rules {
+				t.Errorf("rules = %+v, want %+v", g.rules, tt.rules)
+			}
+			if g.board.Rows != tt.rules.Rows || g.board.Cols != tt.rules.Cols || len(g.board.Cells) != len(want.Board.Cells) {
+				t.Fatalf("board is %dx%d with %d cells", g.board.Rows, g.board.Cols, len(g.board.Cells))
+			}
+			for i, v := range want.Board.Cells {
+				if g.board.Cells[i] != v {
+					t.Fatalf("cell %d = %d, want %d", i, g.board.Cells[i], v)
+				}
+			}
+			if g.toMove != tt.toMove {
+				t.Errorf("toMove = %d, want %d", g.toMove, tt.toMove)
+			}
+			if g.winner != tt.winner {
+				t.Errorf("winner = %q, want %q", g.winner, tt.winner)
+			}
+			if g.current == nil {
+				t.Error("the game's state didn't load")
+			}
+		})
+	}
+}
+
+// a rules change has to come in whole, never a board of one size with the
+// rules of another
+func TestUpdateChangesRules(t *testing.T) {
+	f := newFakeBackend()
+	g := playing(f)
+	f.send(t, game.TypeUpdate, update(t, game.Classic, "a1"))
+	g.pump()
+	big := game.Settings{Rows: 15, Cols: 15, K: 5}
+	f.send(t, game.TypeUpdate, update(t, big, "h8"))
+	g.pump()
+	if g.rules != big || g.board.Rows != 15 || len(g.board.Cells) != 15*15 {
+		t.Errorf("rules %+v with a %dx%d board", g.rules, g.board.Rows, g.board.Cols)
+	}
+	if g.board.At(7, 7) != 1 {
+		t.Error("h8 isn't on the board")
+	}
+}
+
+// events only change the game when Update pumps them, nothing happens in
+// between frames
+func TestEventsWaitForPump(t *testing.T) {
+	f := newFakeBackend()
+	g := playing(f)
+	f.send(t, game.TypeUpdate, update(t, game.Classic, "b2"))
+	if g.board.At(1, 1) != 0 {
+		t.Fatal("the update was applied before the pump")
+	}
+	g.pump()
+	if g.board.At(1, 1) != 1 {
+		t.Fatal("the update wasn't applied by the pump")
+	}
+}
+
+// updates from a backend we're not playing through are dropped, anything
+// else from it still shows up
+func TestOtherBackend(t *testing.T) {
+	f, other := newFakeBackend(), newFakeBackend()
+	g := playing(f)
+	other.send(t, game.TypeUpdate, update(t, game.Classic, "b2"))
+	other.send(t, game.TypeChat, game.Message{From: "bob", Text: "hi"})
+	g.drain(other)
+	if g.board.At(1, 1) != 0 {
+		t.Error("an update from the other backend was applied")
+	}
+	if len(g.chat) != 1 || g.chat[0].Text != "hi" {
+		t.Errorf("chat = %v, want the message from the other backend", g.chat)
+	}
+}
+
+// a closed backend doesn't hold the game loop up
+func TestClosedBackend(t *testing.T) {
+	f := newFakeBackend()
+	g := playing(f)
+	f.send(t, game.TypeChat, game.Message{Text: "bye"})
+	f.Leave()
+	g.pump()
+	g.pump()
+	if len(g.chat) != 1 {
+		t.Errorf("chat = %v, want the message sent before it closed", g.chat)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	f := newFakeBackend()
+	g := playing(f)
+	f.send(t, game.TypeError, game.Error{Text: "that cell is taken"})
+	g.pump()
+	if len(g.chat) != 1 || g.chat[0].Text != "that cell is taken" {
+		t.Errorf("chat = %v, want the error in it", g.chat)
+	}
+
+	g.state = StateLogin
+	f.send(t, game.TypeError, game.Error{Text: "wrong password"})
+	g.pump()
+	if g.authErr != "wrong password" {
+		t.Errorf("authErr = %q", g.authErr)
+	}
+}
+
+func TestMove(t *testing.T) {
+	f := newFakeBackend()
+	g := playing(f)
+	in := game.Input{Player: 1, Pos: game.Pos{Row: 2, Col: 0}}
+	g.move(in)
+	if len(f.moves) != 1 || f.moves[0] != in {
+		t.Fatalf("moves = %v, want %v", f.moves, in)
+	}
+	if len(g.chat) != 0 {
+		t.Errorf("chat = %v after a good move", g.chat)
+	}
+
+	f.err = errors.New("it's not your turn")
+	g.move(in)
+	if len(g.chat) != 1 || g.chat[0].Text != "it's not your turn" {
+		t.Errorf("chat = %v, want the error in it", g.chat)
+	}
+}
+
+// local and bot games come through the same pump as the server's
+func TestLocalGame(t *testing.T) {
+	tests := []struct {
+		name  string
+		bot   bool
+		marks int // on the board after our move
+	}{
+		{"hot-seat", false, 1},
+		{"bot", true, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGame()
+			g.startLocal(tt.bot)
+			g.pump()
+			if g.local == nil || g.state != StatePlaying || g.player != 1 {
+				t.Fatalf("no local game, state %d player %d", g.state, g.player)
+			}
+			g.move(game.Input{Player: 1, Pos: game.Pos{Row: 1, Col: 1}})
+			g.pump()
+			marks := 0
+			for _, v := range g.board.Cells {
+				if v != 0 {
+					marks++
+				}
+			}
+			if g.board.At(1, 1) != 1 || marks != tt.marks {
+				t.Errorf("board %v, want our X in the middle and %d marks", g.board.Cells, tt.marks)
+			}
+			if len(g.moves) != tt.marks {
+				t.Errorf("%d moves, want %d", len(g.moves), tt.marks)
+			}
+		})
+	}
+}
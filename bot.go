@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+
+	"tictactoe/game"
+)
+
+// botBackend is a local game against the computer. We're player 1 and the
+// bot plays every other seat, straight after our move.
+type botBackend struct {
+	*localBackend
+	book *game.Search // perfect play out of the opening book, nil without one
+}
+
+// newBotBackend starts a game against the bot, book is the opening book
+// for rules if there is one
+func newBotBackend(rules game.Settings, book *game.Book) (*botBackend, error) {
+	l := &localBackend{rules: rules, events: make(chan game.Envelope, 16), human: 1}
+	l.names = []string{"You"}
+	for seat := 2; seat <= rules.Seats(); seat++ {
+		l.names = append(l.names, "Bot")
+	}
+	b := &botBackend{localBackend: l}
+	if book != nil {
+		b.book = game.NewSearch(rules, 0)
+		if err := b.book.UseBook(book); err != nil {
+			return nil, err
+		}
+	}
+	return b, l.start()
+}
+
+func (b *botBackend) SubmitMove(in game.Input) error {
+	if err := b.localBackend.SubmitMove(in); err != nil {
+		return err
+	}
+	b.play()
+	return nil
+}
+
+func (b *botBackend) RequestRematch() error {
+	if err := b.localBackend.RequestRematch(); err != nil {
+		return err
+	}
+	b.play() // in case we don't go first
+	return nil
+}
+
+// play makes the bot's moves until it's our turn again
+func (b *botBackend) play() {
+	for b.state.Outcome() == "" && b.state.ToMove() != b.human {
+		var in game.Input
+		var ok bool
+		if b.book != nil {
+			in, ok = b.book.Best(b.state)
+		} else {
+			in, ok = botMove(b.rules, b.state)
+		}
+		if !ok {
+			return
+		}
+		if err := b.apply(in); err != nil {
+			fmt.Println("bot move error:", err)
+			return
+		}
+		b.update()
+	}
+}
+
+// over 150 moves to choose from checking every answer gets slow, the bot
+// just takes wins there
+const botLookahead = 150
+
+// botMove picks a move for whoever's turn it is: one that wins if there is
+// one, otherwise one that doesn't let the next player win straight away,
+// otherwise anything
+func botMove(rules game.Settings, state game.State) (game.Input, bool) {
+	moves := state.Moves()
+	if len(moves) == 0 {
+		return game.Input{}, false
+	}
+	rand.Shuffle(len(moves), func(i, j int) { moves[i], moves[j] = moves[j], moves[i] })
+
+	me := fmt.Sprintf("Player %d", state.ToMove())
+	var safe []game.Input
+	for _, in := range moves {
+		next, ok := game.After(rules, state, in)
+		if !ok {
+			continue
+		}
+		if next.Outcome() == me {
+			return in, true
+		}
+		if len(moves) <= botLookahead && !loses(rules, next, me) {
+			safe = append(safe, in)
+		}
+	}
+	if len(safe) > 0 {
+		return safe[0], true
+	}
+	return moves[0], true
+}
+
+// loses is whether the next move can win for somebody else
+func loses(rules game.Settings, state game.State, me string) bool {
+	if state.Outcome() != "" {
+		return state.Outcome() != me && state.Outcome() != "CAT"
+	}
+	for _, in := range state.Moves() {
+		next, ok := game.After(rules, state, in)
+		if ok && next.Outcome() != "" && next.Outcome() != me && next.Outcome() != "CAT" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"image/color"
+	"unicode/utf8"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+
+	"tictactoe/game"
+)
+
+// chat panel, it lives in the strip under the board
+const (
+	chatLines     = 4   // messages shown at once
+	chatKeep      = 50  // messages remembered
+	chatMaxLength = 200 // same limit the server has
+	chatTop       = 508
+	chatLineH     = 18
+)
+
+// updateChat handles the keyboard while the chat box is open. It returns
+// true when it used the input so the board doesn't react to it too.
+func (g *Game) updateChat() bool {
+	if !g.typing {
+		// Enter or T opens the chat box
+		if inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeyT) {
+			g.typing = true
+			g.chatInput = ""
+			return true
+		}
+		return false
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.typing = false
+		return true
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		if g.chatInput != "" {
+			g.backend().Chat(g.chatInput)
+		}
+		g.typing = false
+		return true
+	}
+
+	for _, r := range ebiten.AppendInputChars(nil) {
+		if utf8.RuneCountInString(g.chatInput) < chatMaxLength {
+			g.chatInput += string(r)
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(g.chatInput) > 0 {
+		_, size := utf8.DecodeLastRuneInString(g.chatInput)
+		g.chatInput = g.chatInput[:len(g.chatInput)-size]
+	}
+	return true
+}
+
+// addChat runs on the game loop, from pump, for every chat message
+func (g *Game) addChat(m game.Message) {
+	g.chat = append(g.chat, m)
+	if len(g.chat) > chatKeep {
+		g.chat = g.chat[len(g.chat)-chatKeep:]
+	}
+}
+
+func (g *Game) drawChat(screen *ebiten.Image) {
+	gray := color.RGBA{160, 160, 160, 255}
+
+	lines := chatLines
+	if g.typing {
+		lines-- // the input box takes the bottom line
+	}
+	start := len(g.chat) - lines
+	if start < 0 {
+		start = 0
+	}
+
+	y := chatTop + chatLineH
+	for _, m := range g.chat[start:] {
+		if m.From == "" {
+			text.Draw(screen, m.Text, g.tinyFont, 10, y, gray)
+		} else {
+			text.Draw(screen, m.From+": "+m.Text, g.tinyFont, 10, y, color.White)
+		}
+		y += chatLineH
+	}
+
+	if g.typing {
+		// only the end of a long message fits, like a normal text box
+		shown := g.chatInput
+		for utf8.RuneCountInString(shown) > 60 {
+			_, size := utf8.DecodeRuneInString(shown)
+			shown = shown[size:]
+		}
+		boxY := float64(chatTop + lines*chatLineH + 4)
+		ebitenutil.DrawRect(screen, 5, boxY, float64(g.mX-10), chatLineH+4, color.RGBA{55, 55, 55, 255})
+		text.Draw(screen, "> "+shown+"_", g.tinyFont, 10, int(boxY)+chatLineH-2, color.White)
+	} else if len(g.chat) == 0 {
+		text.Draw(screen, "Press T to chat", g.tinyFont, 10, chatTop+chatLineH, gray)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+
+	"tictactoe/game"
+)
+
+// 3D boards are drawn as their layers in two columns, top layer first,
+// with a gap between them for the layer names
+const cubeGap = 30
+
+// room left between the bottom layers and the chat
+const cubeChatGap = 10
+
+// cubeLayer is where the top left corner of a layer is drawn
+func (g *Game) cubeLayer(layer int) (int, int) {
+	size := g.board.Cols*g.cellSize + cubeGap
+	return g.offset + layer%2*size, g.offset + layer/2*size
+}
+
+// cubeCell finds the cell under the mouse
+func (g *Game) cubeCell(x, y int) (game.Pos, bool) {
+	for layer := 0; layer < g.board.Layers; layer++ {
+		lx, ly := g.cubeLayer(layer)
+		if x < lx || y < ly {
+			continue
+		}
+		p := game.Pos{Layer: layer, Row: (y - ly) / g.cellSize, Col: (x - lx) / g.cellSize}
+		if g.board.Contains(p) {
+			return p, true
+		}
+	}
+	return game.Pos{}, false
+}
+
+// drawCube draws every layer with its marks. The winning line can go
+// through the layers so instead of a line its cells are lit up. Notakto
+// uses the layers as its boards, there the line of every dead board is.
+func (g *Game) drawCube(screen *ebiten.Image) {
+	notakto := g.rules.Variant == game.VariantNotakto
+	if notakto {
+		for layer := 0; layer < g.board.Layers; layer++ {
+			if line, ok := g.board.Layer(layer).Marks().FindLine(3); ok {
+				line.From.Layer, line.To.Layer = layer, layer
+				g.lightUp(screen, line, color.RGBA{160, 0, 0, 255})
+			}
+		}
+	} else if line, ok := g.board.FindLine(g.rules.K); ok {
+		g.lightUp(screen, line, color.RGBA{0, 160, 0, 255})
+	}
+
+	width := float64(g.board.Cols * g.cellSize)
+	height := float64(g.board.Rows * g.cellSize)
+	for layer := 0; layer < g.board.Layers; layer++ {
+		lx, ly := g.cubeLayer(layer)
+		x0, y0 := float64(lx), float64(ly)
+		label := fmt.Sprintf("Layer %d", layer+1)
+		if notakto {
+			label = fmt.Sprintf("Board %d", layer+1)
+		}
+		text.Draw(screen, label, g.tinyFont, lx, ly-6, color.RGBA{160, 160, 160, 255})
+
+		for col := 0; col <= g.board.Cols; col++ {
+			x := x0 + float64(col*g.cellSize)
+			ebitenutil.DrawLine(screen, x, y0, x, y0+height, color.White)
+		}
+		for row := 0; row <= g.board.Rows; row++ {
+			y := y0 + float64(row*g.cellSize)
+			ebitenutil.DrawLine(screen, x0, y, x0+width, y, color.White)
+		}
+
+		for row := 0; row < g.board.Rows; row++ {
+			for col := 0; col < g.board.Cols; col++ {
+				p := game.Pos{Layer: layer, Row: row, Col: col}
+				var img *ebiten.Image
+				switch v := g.board.Get(p); {
+				case v == 0:
+					continue
+				case v == 1 || notakto:
+					img = g.imageX
+				default:
+					img = g.imageO
+				}
+				x, y := g.cellCorner(p)
+				op := &ebiten.DrawImageOptions{}
+				op.GeoM.Scale(float64(g.cellSize)/float64(img.Bounds().Dx()), float64(g.cellSize)/float64(img.Bounds().Dy()))
+				op.GeoM.Translate(x, y)
+				screen.DrawImage(img, op)
+			}
+		}
+	}
+}
+
+// cellCorner is the top left corner of a cell on the screen
+func (g *Game) cellCorner(p game.Pos) (float64, float64) {
+	lx, ly := g.cubeLayer(p.Layer)
+	return float64(lx + p.Col*g.cellSize), float64(ly + p.Row*g.cellSize)
+}
+
+// lightUp fills in the cells of a line
+func (g *Game) lightUp(screen *ebiten.Image, line game.Line, clr color.Color) {
+	for _, p := range line.Cells() {
+		x, y := g.cellCorner(p)
+		ebitenutil.DrawRect(screen, x, y, float64(g.cellSize), float64(g.cellSize), clr)
+	}
+}
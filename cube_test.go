@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"tictactoe/game"
+)
+
+// every 3D board fits between the left edge of the board and the chat
+func TestCubeFits(t *testing.T) {
+	tests := []game.Settings{
+		game.Qubic,
+		{Layers: 3, Rows: 3, Cols: 3, K: 3},
+		{Layers: 2, Rows: 3, Cols: 3, K: 3, Variant: game.VariantNotakto},
+		{Layers: game.MaxNotaktoBoards, Rows: 3, Cols: 3, K: 3, Variant: game.VariantNotakto},
+	}
+	for _, rules := range tests {
+		t.Run(rules.Name(), func(t *testing.T) {
+			g := NewGame()
+			g.setRules(rules)
+			g.resetBoard()
+			for layer := 0; layer < rules.Layers; layer++ {
+				x, y := g.cubeLayer(layer)
+				right, bottom := x+rules.Cols*g.cellSize, y+rules.Rows*g.cellSize
+				if right > g.offset+boardPixels || bottom > chatTop-cubeChatGap {
+					t.Errorf("layer %d reaches %d, %d with %dpx cells", layer+1, right, bottom, g.cellSize)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package game
+
+import "fmt"
+
+// Pos is one cell of a board. Layer is only used by 3D boards, where layer
+// 0 is the top one; flat boards only have layer 0.
+type Pos struct {
+	Layer int `json:",omitempty"`
+	Row   int
+	Col   int
+}
+
+// Board is a Layers x Rows x Cols grid stored layer by layer, row by row. 0
+// is an empty cell, a positive number is the player who played there and a
+// negative one is a cell nobody owns that can't be played (like a drawn
+// board in ultimate). Flat boards have one layer.
+type Board struct {
+	Layers int
+	Rows   int
+	Cols   int
+	Cells  []int
+}
+
+func NewBoard(rows, cols int) Board {
+	return NewCube(1, rows, cols)
+}
+
+// NewCube makes a 3D board
+func NewCube(layers, rows, cols int) Board {
+	return Board{Layers: layers, Rows: rows, Cols: cols, Cells: make([]int, layers*rows*cols)}
+}
+
+// In says whether row, col is on the board
+func (b Board) In(row, col int) bool {
+	return b.Contains(Pos{Row: row, Col: col})
+}
+
+func (b Board) At(row, col int) int {
+	return b.Get(Pos{Row: row, Col: col})
+}
+
+func (b Board) Set(row, col, v int) {
+	b.Put(Pos{Row: row, Col: col}, v)
+}
+
+// Contains, Get and Put are In, At and Set for any Pos, layer included
+func (b Board) Contains(p Pos) bool {
+	return p.Layer >= 0 && p.Layer < b.Layers && p.Row >= 0 && p.Row < b.Rows && p.Col >= 0 && p.Col < b.Cols
+}
+
+func (b Board) Get(p Pos) int {
+	return b.Cells[(p.Layer*b.Rows+p.Row)*b.Cols+p.Col]
+}
+
+func (b Board) Put(p Pos, v int) {
+	b.Cells[(p.Layer*b.Rows+p.Row)*b.Cols+p.Col] = v
+}
+
+// pos is the cell at index i of Cells
+func (b Board) pos(i int) Pos {
+	return Pos{Layer: i / (b.Rows * b.Cols), Row: i / b.Cols % b.Rows, Col: i % b.Cols}
+}
+
+// Drop is the row a piece dropped in col lands on, the lowest empty cell.
+// It's false when the column is full.
+func (b Board) Drop(col int) (int, bool) {
+	for row := b.Rows - 1; row >= 0; row-- {
+		if b.At(row, col) == 0 {
+			return row, true
+		}
+	}
+	return 0, false
+}
+
+// Layer is one layer of a 3D board as a flat board. It shares its cells
+// with b.
+func (b Board) Layer(layer int) Board {
+	size := b.Rows * b.Cols
+	return Board{Layers: 1, Rows: b.Rows, Cols: b.Cols, Cells: b.Cells[layer*size : (layer+1)*size]}
+}
+
+// Marks is a copy of b where every mark is 1, for games where everybody
+// plays the same symbol
+func (b Board) Marks() Board {
+	c := b.Clone()
+	for i, v := range c.Cells {
+		if v > 0 {
+			c.Cells[i] = 1
+		}
+	}
+	return c
+}
+
+// Clone makes a copy that can be changed without touching b
+func (b Board) Clone() Board {
+	c := b
+	c.Cells = append([]int(nil), b.Cells...)
+	return c
+}
+
+func (b Board) Full() bool {
+	for _, v := range b.Cells {
+		if v == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Line is k cells in a row, From and To are the two ends
+type Line struct {
+	Player int
+	From   Pos
+	To     Pos
+}
+
+// Cells lists every cell of the line from From to To
+func (l Line) Cells() []Pos {
+	step := Pos{Layer: sign(l.To.Layer - l.From.Layer), Row: sign(l.To.Row - l.From.Row), Col: sign(l.To.Col - l.From.Col)}
+	cells := []Pos{l.From}
+	for p := l.From; p != l.To; {
+		p = Pos{Layer: p.Layer + step.Layer, Row: p.Row + step.Row, Col: p.Col + step.Col}
+		cells = append(cells, p)
+	}
+	return cells
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	}
+	return 0
+}
+
+// the ways a line can go: right, down, down-right and down-left on one
+// layer, and the nine that go down through the layers. Going the other way
+// finds the same lines, so half of the 26 directions is enough.
+var directions = []Pos{
+	{0, 0, 1}, {0, 1, 0}, {0, 1, 1}, {0, 1, -1},
+	{1, 0, 0}, {1, 0, 1}, {1, 0, -1}, {1, 1, 0}, {1, -1, 0},
+	{1, 1, 1}, {1, 1, -1}, {1, -1, 1}, {1, -1, -1},
+}
+
+// FindLine looks for k cells in a row owned by the same player. Only the
+// first line found is returned.
+func (b Board) FindLine(k int) (Line, bool) {
+	for i, p := range b.Cells {
+		if p <= 0 {
+			continue
+		}
+		from := b.pos(i)
+		for _, d := range directions {
+			to := Pos{Layer: from.Layer + d.Layer*(k-1), Row: from.Row + d.Row*(k-1), Col: from.Col + d.Col*(k-1)}
+			if !b.Contains(to) {
+				continue
+			}
+			n := 1
+			for n < k && b.Get(Pos{Layer: from.Layer + d.Layer*n, Row: from.Row + d.Row*n, Col: from.Col + d.Col*n}) == p {
+				n++
+			}
+			if n == k {
+				return Line{Player: p, From: from, To: to}, true
+			}
+		}
+	}
+	return Line{}, false
+}
+
+// Outcome is "Player N" once somebody has k in a row, "CAT" when the board
+// is full without one and "" while the game is still going
+func Outcome(b Board, k int) string {
+	if line, ok := b.FindLine(k); ok {
+		return fmt.Sprintf("Player %d", line.Player)
+	}
+	if b.Full() {
+		return "CAT"
+	}
+	return ""
+}
@@ -0,0 +1,216 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Book is every position of a small board and how it ends with perfect
+// play, worked out once by BuildBook and saved so nothing has to search
+// the game again. Positions that are the same turned or flipped share an
+// entry, which leaves 765 on a 3x3 board.
+type Book struct {
+	Rules Settings
+	// the position (see key) and its Value for the player whose turn it
+	// is, Moves is 0 for the ones where the game is over
+	Positions map[string]Value
+}
+
+// Bookable is whether rules can have a book: the solver has to know the
+// board, and turning or flipping it can't change the game, which rules out
+// gravity and the house rules with their own shapes
+func Bookable(rules Settings) bool {
+	switch rules.Variant {
+	case "", VariantMisere, VariantWild, VariantNotakto:
+	default:
+		return false
+	}
+	return Solvable(rules) && rules.Rows == rules.Cols && rules.Layers <= 1 && !rules.Gravity
+}
+
+// BuildBook goes through every game on the board and solves each position
+// it comes across
+func BuildBook(rules Settings) (*Book, error) {
+	if !Bookable(rules) {
+		return nil, fmt.Errorf("can't make a book for %s", rules.Name())
+	}
+	solver, err := NewSolver(rules)
+	if err != nil {
+		return nil, err
+	}
+	start, err := rules.Start()
+	if err != nil {
+		return nil, err
+	}
+	b := &Book{Rules: rules, Positions: map[string]Value{}}
+	var walk func(state State)
+	walk = func(state State) {
+		key := b.key(state)
+		if _, ok := b.Positions[key]; ok {
+			return // got there another way, or it's this one turned
+		}
+		b.Positions[key] = solver.Solve(state)
+		if state.Outcome() != "" {
+			return
+		}
+		for _, in := range state.Moves() {
+			if next, ok := After(rules, state, in); ok {
+				walk(next)
+			}
+		}
+	}
+	walk(start)
+	return b, nil
+}
+
+// LoadBook reads a book saved by Save
+func LoadBook(path string) (*Book, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b Book
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if !Bookable(b.Rules) || len(b.Positions) == 0 {
+		return nil, fmt.Errorf("%s isn't a book", path)
+	}
+	return &b, nil
+}
+
+// Save writes the book out as JSON
+func (b *Book) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Lookup is how state ends with perfect play, if it's in the book
+func (b *Book) Lookup(state State) (Value, bool) {
+	v, ok := b.Positions[b.key(state)]
+	return v, ok
+}
+
+// key writes out the board, turned and flipped whichever way comes first
+// in order, and whose turn it is. A position and its turned and flipped
+// copies all get the same key.
+func (b *Book) key(state State) string {
+	grid := state.Grid()
+	n := grid.Rows
+	best := ""
+	for t := 0; t < 8; t++ {
+		var sb strings.Builder
+		for row := 0; row < n; row++ {
+			for col := 0; col < n; col++ {
+				r, c := row, col
+				if t&1 != 0 {
+					c = n - 1 - c // mirrored
+				}
+				if t&2 != 0 {
+					r = n - 1 - r // upside down
+				}
+				if t&4 != 0 {
+					r, c = c, r // along the diagonal
+				}
+				sb.WriteByte(cellChar(grid.At(r, c)))
+			}
+		}
+		if s := sb.String(); best == "" || s < best {
+			best = s
+		}
+	}
+	return best + "/" + strconv.Itoa(state.ToMove())
+}
+
+// cellChar is how a cell looks in a key
+func cellChar(v int) byte {
+	switch {
+	case v < 0:
+		return '#'
+	case v == 0:
+		return '.'
+	case v == X:
+		return 'x'
+	case v == O:
+		return 'o'
+	}
+	return byte('0' + v)
+}
+
+// BookStats counts what's in a book
+type BookStats struct {
+	Positions int
+	// positions where the game is over, by how it went
+	Over, Player1, Player2, Draws int
+	// the others, by how they end for the player whose turn it is
+	Wins, Losses, Drawn int
+	// all of them by how many moves have been made
+	ByMoves map[int]int
+}
+
+// Stats counts the book's positions
+func (b *Book) Stats() BookStats {
+	st := BookStats{ByMoves: map[int]int{}}
+	for key, v := range b.Positions {
+		st.Positions++
+		board := key[:strings.LastIndexByte(key, '/')]
+		st.ByMoves[len(board)-strings.Count(board, ".")-strings.Count(board, "#")]++
+		if v.Moves == 0 {
+			// the Value is for the player whose turn it would have been
+			st.Over++
+			toMove, _ := strconv.Atoi(key[len(board)+1:])
+			switch {
+			case v.Result == 0:
+				st.Draws++
+			case (v.Result > 0) == (toMove == 1):
+				st.Player1++
+			default:
+				st.Player2++
+			}
+			continue
+		}
+		switch v.Result {
+		case 1:
+			st.Wins++
+		case -1:
+			st.Losses++
+		default:
+			st.Drawn++
+		}
+	}
+	return st
+}
+
+// String lays the stats out a line each
+func (st BookStats) String() string {
+	var lines []string
+	lines = append(lines, fmt.Sprintf("positions    %d", st.Positions))
+	lines = append(lines, fmt.Sprintf("game over    %d (player 1 won %d, player 2 won %d, drawn %d)", st.Over, st.Player1, st.Player2, st.Draws))
+	lines = append(lines, fmt.Sprintf("still going  %d (to move wins %d, loses %d, draws %d)", st.Positions-st.Over, st.Wins, st.Losses, st.Drawn))
+	var moves []int
+	for n := range st.ByMoves {
+		moves = append(moves, n)
+	}
+	sort.Ints(moves)
+	for _, n := range moves {
+		lines = append(lines, fmt.Sprintf("  after %d moves  %d", n, st.ByMoves[n]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// UseBook makes the solver look positions up in b before working them out
+func (s *Solver) UseBook(b *Book) error {
+	if b.Rules != s.rules {
+		return errors.New("that book is for " + b.Rules.Name())
+	}
+	s.book = b
+	return nil
+}
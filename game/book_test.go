@@ -0,0 +1,192 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBookable(t *testing.T) {
+	tests := []struct {
+		rules Settings
+		want  bool
+	}{
+		{Classic, true},
+		{Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantMisere}, true},
+		{Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantWild}, true},
+		{Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantNotakto}, true},
+		{Settings{Rows: 3, Cols: 3, K: 3, Gravity: true}, false},
+		{Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantQuantum}, false},
+		{Settings{Rows: 4, Cols: 4, K: 4}, false},
+	}
+	for _, tt := range tests {
+		if got := Bookable(tt.rules); got != tt.want {
+			t.Errorf("Bookable(%s) = %v, want %v", tt.rules.Name(), got, tt.want)
+		}
+	}
+	if _, err := BuildBook(Settings{Rows: 3, Cols: 3, K: 3, Gravity: true}); err == nil {
+		t.Error("built a book for gravity")
+	}
+}
+
+func TestBuildBook(t *testing.T) {
+	b, err := BuildBook(Classic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := b.Stats()
+	if st.Positions != 765 || len(b.Positions) != 765 {
+		t.Errorf("%d positions, want 765", st.Positions)
+	}
+	if st.Over != 138 || st.Player1 != 91 || st.Player2 != 44 || st.Draws != 3 {
+		t.Errorf("finished games %+v, want 138: 91 for player 1, 44 for player 2 and 3 drawn", st)
+	}
+	want := map[int]int{0: 1, 1: 3, 2: 12, 3: 38, 4: 108, 5: 174, 6: 204, 7: 153, 8: 57, 9: 15}
+	if !reflect.DeepEqual(st.ByMoves, want) {
+		t.Errorf("by moves %v, want %v", st.ByMoves, want)
+	}
+
+	v, ok := b.Lookup(play(t, Classic))
+	if !ok || v != (Value{Result: 0, Moves: 9}) {
+		t.Errorf("the start is %v (%v), want a draw in 9", v, ok)
+	}
+}
+
+// a position turned or flipped is the same entry
+func TestBookKey(t *testing.T) {
+	b := &Book{Rules: Classic}
+	key := func(moves ...string) string {
+		return b.key(play(t, Classic, moves...))
+	}
+	same := [][][]string{
+		{{"a1"}, {"c1"}, {"a3"}, {"c3"}},
+		{{"b1"}, {"a2"}, {"c2"}, {"b3"}},
+		{{"a1", "b1"}, {"c1", "b1"}, {"a1", "a2"}, {"c3", "c2"}, {"a3", "b3"}},
+		{{"a1", "b2", "c3"}, {"c3", "b2", "a1"}, {"a3", "b2", "c1"}},
+	}
+	for _, group := range same {
+		for _, moves := range group[1:] {
+			if key(moves...) != key(group[0]...) {
+				t.Errorf("%v and %v have different keys", moves, group[0])
+			}
+		}
+	}
+	different := [][2][]string{
+		{{"a1"}, {"b1"}},
+		{{"a1"}, {"b2"}},
+		{{"a1", "b1"}, {"a1", "c1"}},
+		{{"a1", "b1"}, {"b1", "a1"}}, // same cells, other marks
+	}
+	for _, pair := range different {
+		if key(pair[0]...) == key(pair[1]...) {
+			t.Errorf("%v and %v have the same key", pair[0], pair[1])
+		}
+	}
+}
+
+// a solver with the book agrees with one without it
+func TestUseBook(t *testing.T) {
+	misere := Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantMisere}
+	for _, rules := range []Settings{Classic, misere} {
+		t.Run(rules.Name(), func(t *testing.T) {
+			b, err := BuildBook(rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			plain, err := NewSolver(rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			booked, err := NewSolver(rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := booked.UseBook(b); err != nil {
+				t.Fatal(err)
+			}
+			for _, moves := range [][]string{nil, {"b2"}, {"a1", "b1"}, {"a1", "b2", "c3"}, {"b1", "a1", "c1", "b2"}} {
+				state := play(t, rules, moves...)
+				if got, want := booked.Analyze(state), plain.Analyze(state); !reflect.DeepEqual(got, want) {
+					t.Errorf("%v: with the book %v, without %v", moves, got, want)
+				}
+			}
+		})
+	}
+
+	b, err := BuildBook(misere)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSolver(Classic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UseBook(b); err == nil {
+		t.Error("a classic solver took a misere book")
+	}
+}
+
+// two searches playing from the book never lose, so it's always a draw
+func TestSearchBook(t *testing.T) {
+	b, err := BuildBook(Classic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSearch(Classic, 0)
+	if err := s.UseBook(b); err != nil {
+		t.Fatal(err)
+	}
+	for game := 0; game < 10; game++ {
+		state := play(t, Classic)
+		for state.Outcome() == "" {
+			in, ok := s.Best(state)
+			if !ok {
+				t.Fatal("no move")
+			}
+			if err := state.Apply(in); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if state.Outcome() != "CAT" {
+			t.Fatalf("game %d: %s", game, state.Outcome())
+		}
+	}
+}
+
+func TestBookSaveLoad(t *testing.T) {
+	b, err := BuildBook(Classic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "book.json")
+	if err := b.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadBook(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, b) {
+		t.Error("the book loaded back different")
+	}
+
+	// the one the client ships with is the same too
+	shipped, err := LoadBook(filepath.Join("..", "book.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(shipped, b) {
+		t.Error("book.json is out of date, make it again with the solve command")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"Rules": {"Rows": 4, "Cols": 4, "K": 4}, "Positions": {"x/1": {}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBook(path); err == nil {
+		t.Error("loaded a book for 4x4")
+	}
+	if _, err := LoadBook(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("loaded a missing book")
+	}
+}
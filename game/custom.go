@@ -0,0 +1,269 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// CustomRules is a variant written in a JSON file so house rules can be
+// tried out without changing any code. The server loads them with
+// LoadVariants when it starts.
+type CustomRules struct {
+	Name        string // what goes in Settings.Variant
+	Description string `json:",omitempty"`
+	Rows        int
+	Cols        int
+	// K in a row in any direction wins, 0 if only Patterns count
+	K int `json:",omitempty"`
+	// [row, col] of cells nobody can play in
+	Blocked [][2]int `json:",omitempty"`
+	// shapes that win wherever they are on the board, as [row, col] of each
+	// cell counted from the first one
+	Patterns [][][2]int `json:",omitempty"`
+	Players  int        `json:",omitempty"` // 0 is 2
+	// seats in the order they move, over and over. 0 means 1, 2, ... like
+	// normal
+	Order []int `json:",omitempty"`
+	// making a winning shape loses instead, only for 2 players
+	Misere bool `json:",omitempty"`
+}
+
+// LoadVariants reads every .json file in dir as CustomRules, checks them and
+// registers them. A missing dir just means there are none.
+func LoadVariants(dir string) ([]CustomRules, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var list []CustomRules
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var rules CustomRules
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields() // catches typos in the file
+		if err := dec.Decode(&rules); err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		if err := rules.Register(); err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		list = append(list, rules)
+	}
+	return list, nil
+}
+
+// Check makes sure the rules make a game that can be played
+func (c CustomRules) Check() error {
+	if c.Name == "" {
+		return errors.New("the variant needs a Name")
+	}
+	for _, r := range c.Name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+			return errors.New("names are lowercase letters, numbers and -")
+		}
+	}
+	if _, err := Lookup(c.Name); err == nil {
+		return fmt.Errorf("there's already a variant called %q", c.Name)
+	}
+	if c.Rows < 3 || c.Cols < 3 || c.Rows > MaxBoardSize || c.Cols > MaxBoardSize {
+		return fmt.Errorf("boards have to be between 3x3 and %dx%d", MaxBoardSize, MaxBoardSize)
+	}
+	seats := c.Settings().Seats()
+	if c.Players < 0 || c.Players == 1 || c.Players > MaxPlayers {
+		return fmt.Errorf("games are for 2 to %d players", MaxPlayers)
+	}
+	if c.Misere && seats != 2 {
+		return errors.New("misere only works with 2 players")
+	}
+
+	if c.K == 0 && len(c.Patterns) == 0 {
+		return errors.New("there's no way to win, give it a K or some Patterns")
+	}
+	if c.K < 0 || c.K == 1 || (c.K > c.Rows && c.K > c.Cols) {
+		return fmt.Errorf("can't fit %d in a row on a %dx%d board", c.K, c.Rows, c.Cols)
+	}
+	for i, pattern := range c.Patterns {
+		if len(pattern) < 2 {
+			return fmt.Errorf("pattern %d needs at least 2 cells", i+1)
+		}
+		if len(c.placements(pattern)) == 0 {
+			return fmt.Errorf("pattern %d doesn't fit on the board", i+1)
+		}
+	}
+
+	seen := map[[2]int]bool{}
+	for _, cell := range c.Blocked {
+		if cell[0] < 0 || cell[0] >= c.Rows || cell[1] < 0 || cell[1] >= c.Cols {
+			return fmt.Errorf("blocked cell %v is off the board", cell)
+		}
+		if seen[cell] {
+			return fmt.Errorf("blocked cell %v is in there twice", cell)
+		}
+		seen[cell] = true
+	}
+	if len(c.Blocked) >= c.Rows*c.Cols {
+		return errors.New("every cell is blocked")
+	}
+
+	moves := map[int]bool{}
+	for _, seat := range c.Order {
+		if seat < 1 || seat > seats {
+			return fmt.Errorf("seat %d in Order isn't one of the %d players", seat, seats)
+		}
+		moves[seat] = true
+	}
+	if len(c.Order) > 0 && len(moves) != seats {
+		return errors.New("everybody has to get a turn in Order")
+	}
+	return nil
+}
+
+// Register checks the rules and makes them a variant rooms can be opened
+// with. Clients do this with the ones the server sends them.
+func (c CustomRules) Register() error {
+	if err := c.Check(); err != nil {
+		return err
+	}
+	Register(c.Name, newCustom(c))
+	return nil
+}
+
+// Settings are what a client asks for to play these rules
+func (c CustomRules) Settings() Settings {
+	return Settings{Rows: c.Rows, Cols: c.Cols, K: c.K, Variant: c.Name, Players: c.Players}
+}
+
+// placements lists every place on the board pattern fits, as the cells it
+// covers
+func (c CustomRules) placements(pattern [][2]int) [][]Pos {
+	b := NewBoard(c.Rows, c.Cols)
+	var list [][]Pos
+	for row := 0; row < c.Rows; row++ {
+		for col := 0; col < c.Cols; col++ {
+			cells := make([]Pos, 0, len(pattern))
+			for _, d := range pattern {
+				p := Pos{Row: row + d[0], Col: col + d[1]}
+				if !b.Contains(p) {
+					break
+				}
+				cells = append(cells, p)
+			}
+			if len(cells) == len(pattern) {
+				list = append(list, cells)
+			}
+		}
+	}
+	return list
+}
+
+// custom is the Variant for a CustomRules
+type custom struct {
+	rules CustomRules
+	wins  [][]Pos // every set of cells that wins, K in a row included
+}
+
+func newCustom(rules CustomRules) custom {
+	v := custom{rules: rules}
+	patterns := rules.Patterns
+	if rules.K > 0 {
+		for _, d := range directions[:4] {
+			line := make([][2]int, rules.K)
+			for i := range line {
+				line[i] = [2]int{i * d.Row, i * d.Col}
+			}
+			patterns = append(patterns, line)
+		}
+	}
+	for _, pattern := range patterns {
+		v.wins = append(v.wins, rules.placements(pattern)...)
+	}
+	return v
+}
+
+func (v custom) Validate(s Settings) error {
+	if s != v.rules.Settings() {
+		return fmt.Errorf("%s only comes as %s", v.rules.Name, v.rules.Settings().Name())
+	}
+	return nil
+}
+
+func (v custom) Start(s Settings) State {
+	g := v.game(s)
+	g.Board = s.NewBoard()
+	g.Turn = 1
+	for _, cell := range v.rules.Blocked {
+		g.Board.Set(cell[0], cell[1], -1)
+	}
+	return g
+}
+
+func (v custom) Load(s Settings, data []byte) (State, error) {
+	g := v.game(s)
+	err := json.Unmarshal(data, g)
+	return g, err
+}
+
+func (v custom) game(s Settings) *CustomGame {
+	return &CustomGame{PlainGame: PlainGame{rules: s, outcome: v.outcome}, order: v.rules.Order}
+}
+
+// outcome checks every winning shape. Blocked cells count as full so a
+// board that only has them left is a draw.
+func (v custom) outcome(b Board, s Settings) string {
+	for _, cells := range v.wins {
+		player := b.Get(cells[0])
+		for _, p := range cells[1:] {
+			if b.Get(p) != player {
+				player = 0
+				break
+			}
+		}
+		if player <= 0 {
+			continue
+		}
+		if v.rules.Misere {
+			player = 3 - player
+		}
+		return fmt.Sprintf("Player %d", player)
+	}
+	if b.Full() {
+		return "CAT"
+	}
+	return ""
+}
+
+// CustomGame is a game of a variant from a file, the board and the turn
+// order
+type CustomGame struct {
+	PlainGame
+	order []int
+}
+
+func (g *CustomGame) ToMove() int {
+	if len(g.order) == 0 {
+		return g.PlainGame.ToMove()
+	}
+	return g.order[(g.Turn-1)%len(g.order)]
+}
+
+func (g *CustomGame) Moves() []Input {
+	moves := g.PlainGame.Moves()
+	for i := range moves {
+		moves[i].Player = g.ToMove()
+	}
+	return moves
+}
+
+func (g *CustomGame) Save() ([]byte, error) {
+	return json.Marshal(g)
+}
@@ -0,0 +1,171 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// register makes c a variant for the test, once, and gives back its
+// settings
+func register(t *testing.T, c CustomRules) Settings {
+	t.Helper()
+	if _, err := Lookup(c.Name); err != nil {
+		if err := c.Register(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c.Settings()
+}
+
+// like the server's double.json and squares.json
+var (
+	testDouble = CustomRules{Name: "test-double", Rows: 4, Cols: 4, K: 3, Order: []int{1, 2, 2, 1}, Misere: true}
+	testSquare = CustomRules{
+		Name: "test-squares", Rows: 5, Cols: 5, K: 4,
+		Blocked:  [][2]int{{2, 2}},
+		Patterns: [][][2]int{{{0, 0}, {0, 1}, {1, 0}, {1, 1}}},
+	}
+)
+
+func TestCustomCheck(t *testing.T) {
+	square := [][][2]int{{{0, 0}, {0, 1}, {1, 0}, {1, 1}}}
+	tests := []struct {
+		name string
+		c    CustomRules
+		ok   bool
+	}{
+		{"fine", CustomRules{Name: "test-fine", Rows: 3, Cols: 3, K: 3}, true},
+		{"only patterns", CustomRules{Name: "test-fine", Rows: 3, Cols: 3, Patterns: square}, true},
+		{"no name", CustomRules{Rows: 3, Cols: 3, K: 3}, false},
+		{"capitals", CustomRules{Name: "Test", Rows: 3, Cols: 3, K: 3}, false},
+		{"taken name", CustomRules{Name: VariantMisere, Rows: 3, Cols: 3, K: 3}, false},
+		{"too small", CustomRules{Name: "test-bad", Rows: 2, Cols: 3, K: 2}, false},
+		{"too big", CustomRules{Name: "test-bad", Rows: 20, Cols: 3, K: 3}, false},
+		{"one player", CustomRules{Name: "test-bad", Rows: 3, Cols: 3, K: 3, Players: 1}, false},
+		{"misere for three", CustomRules{Name: "test-bad", Rows: 5, Cols: 5, K: 3, Players: 3, Misere: true}, false},
+		{"no way to win", CustomRules{Name: "test-bad", Rows: 3, Cols: 3}, false},
+		{"one in a row", CustomRules{Name: "test-bad", Rows: 3, Cols: 3, K: 1}, false},
+		{"k doesn't fit", CustomRules{Name: "test-bad", Rows: 3, Cols: 3, K: 4}, false},
+		{"pattern of one", CustomRules{Name: "test-bad", Rows: 3, Cols: 3, Patterns: [][][2]int{{{0, 0}}}}, false},
+		{"pattern doesn't fit", CustomRules{Name: "test-bad", Rows: 3, Cols: 3, Patterns: [][][2]int{{{0, 0}, {0, 3}}}}, false},
+		{"blocked off the board", CustomRules{Name: "test-bad", Rows: 3, Cols: 3, K: 3, Blocked: [][2]int{{3, 0}}}, false},
+		{"blocked twice", CustomRules{Name: "test-bad", Rows: 3, Cols: 3, K: 3, Blocked: [][2]int{{1, 1}, {1, 1}}}, false},
+		{"all blocked", CustomRules{Name: "test-bad", Rows: 3, Cols: 3, K: 3, Blocked: [][2]int{
+			{0, 0}, {0, 1}, {0, 2}, {1, 0}, {1, 1}, {1, 2}, {2, 0}, {2, 1}, {2, 2},
+		}}, false},
+		{"order with a stranger", CustomRules{Name: "test-bad", Rows: 3, Cols: 3, K: 3, Order: []int{1, 3}}, false},
+		{"order leaves one out", CustomRules{Name: "test-bad", Rows: 3, Cols: 3, K: 3, Order: []int{1, 1}}, false},
+	}
+	for _, tt := range tests {
+		err := tt.c.Check()
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: Check = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestCustomOutcome(t *testing.T) {
+	double, squares := register(t, testDouble), register(t, testSquare)
+	tests := []struct {
+		name  string
+		rules Settings
+		moves []string
+		want  string
+	}{
+		{"square", squares, []string{"a1", "e5", "b1", "e4", "a2", "d5", "b2"}, "Player 1"},
+		{"four in a row", squares, []string{"a1", "e5", "a2", "e3", "a3", "c5", "a4"}, "Player 1"},
+		{"three isn't enough", squares, []string{"a1", "e5", "a2", "e3", "a3"}, ""},
+		{"misere line loses", double, []string{"a1", "d4", "d2", "b1", "c1"}, "Player 2"},
+		{"misere going", double, []string{"a1", "d4", "d2", "b1"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := play(t, tt.rules, tt.moves...)
+			if got := state.Outcome(); got != tt.want {
+				t.Errorf("Outcome = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomBlocked(t *testing.T) {
+	squares := register(t, testSquare)
+	state := play(t, squares)
+	if state.Grid().At(2, 2) != -1 {
+		t.Error("c3 isn't blocked")
+	}
+	if len(state.Moves()) != 24 {
+		t.Errorf("%d moves, want 24", len(state.Moves()))
+	}
+	if err := move(state, squares, "c3"); err == nil {
+		t.Error("a move on the blocked cell was allowed")
+	}
+}
+
+func TestCustomOrder(t *testing.T) {
+	double := register(t, testDouble)
+	state := play(t, double)
+	for turn, want := range []int{1, 2, 2, 1, 1, 2, 2, 1} {
+		if state.ToMove() != want {
+			t.Fatalf("turn %d: player %d to move, want %d", turn+1, state.ToMove(), want)
+		}
+		for _, in := range state.Moves() {
+			if in.Player != want {
+				t.Fatalf("turn %d: a move for player %d", turn+1, in.Player)
+			}
+		}
+		if err := state.Apply(state.Moves()[turn]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the order survives saving
+	data, err := state.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := double.Load(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.ToMove() != 1 || loaded.CurrentTurn() != 9 {
+		t.Errorf("loaded player %d to move on turn %d", loaded.ToMove(), loaded.CurrentTurn())
+	}
+	if err := (Settings{Rows: 5, Cols: 5, K: 3, Variant: testDouble.Name}).Validate(); err == nil {
+		t.Error("test-double was allowed on 5x5")
+	}
+}
+
+func TestLoadVariants(t *testing.T) {
+	if list, err := LoadVariants(filepath.Join(t.TempDir(), "missing")); err != nil || len(list) != 0 {
+		t.Errorf("LoadVariants of a missing dir = %v, %v", list, err)
+	}
+
+	dir := t.TempDir()
+	write := func(name, text string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("file.json", `{"Name": "test-file", "Rows": 4, "Cols": 4, "K": 3}`)
+	list, err := LoadVariants(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Name != "test-file" {
+		t.Fatalf("loaded %+v", list)
+	}
+	if _, err := (Settings{Rows: 4, Cols: 4, K: 3, Variant: "test-file"}).Start(); err != nil {
+		t.Error(err)
+	}
+
+	// a typo is an error, not a field that's quietly left out
+	write("typo.json", `{"Name": "test-typo", "Rows": 4, "Cols": 4, "K": 3, "Blockd": [[0, 0]]}`)
+	if err := os.Remove(filepath.Join(dir, "file.json")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadVariants(dir); err == nil {
+		t.Error("loaded a file with a typo")
+	}
+}
@@ -0,0 +1,32 @@
+package game
+
+import "testing"
+
+// play starts a game on rules and makes moves, written the way ParseMove
+// reads them, for whoever's turn it is
+func play(t *testing.T, rules Settings, moves ...string) State {
+	t.Helper()
+	state, err := rules.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range moves {
+		in, err := ParseMove(rules, state, text)
+		if err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+		if err := state.Apply(in); err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+	}
+	return state
+}
+
+// move makes one more move on state, for the tests that expect it to fail
+func move(state State, rules Settings, text string) error {
+	in, err := ParseMove(rules, state, text)
+	if err != nil {
+		return err
+	}
+	return state.Apply(in)
+}
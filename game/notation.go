@@ -0,0 +1,153 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Moves can be written as text for clients without a mouse. A cell is its
+// column as a letter and its row as a number from the top, so a1 is the
+// top left corner. The rest depends on the variant:
+//
+//	b2      a normal move
+//	2:b2    layer 2 of a 3D board
+//	b       just the column on a gravity board, a row is ignored there
+//	b2=o    the symbol in wild and order and chaos, or the number in
+//	        numerical (b2=7)
+//	a1-c3   a spooky mark in quantum, collapsing one is just the cell
+//
+// Ultimate uses the cells of the whole 9x9 board.
+
+// FormatPos writes p the way ParsePos reads it
+func FormatPos(p Pos) string {
+	s := fmt.Sprintf("%c%d", 'a'+p.Col, p.Row+1)
+	if p.Layer > 0 {
+		s = fmt.Sprintf("%d:%s", p.Layer+1, s)
+	}
+	return s
+}
+
+// ParsePos reads a cell like b2 or 2:b2. It only checks that it's on b.
+func ParsePos(b Board, text string) (Pos, error) {
+	var p Pos
+	text = strings.ToLower(strings.TrimSpace(text))
+	if layer, rest, ok := strings.Cut(text, ":"); ok {
+		n, err := strconv.Atoi(layer)
+		if err != nil {
+			return p, fmt.Errorf("%q isn't a layer", layer)
+		}
+		p.Layer = n - 1
+		text = rest
+	}
+	if text == "" || text[0] < 'a' || text[0] > 'z' {
+		return p, fmt.Errorf("%q should start with the column letter, like b2", text)
+	}
+	p.Col = int(text[0] - 'a')
+	if len(text) == 1 {
+		if p.Col >= b.Cols {
+			return p, fmt.Errorf("%s isn't on the board", text)
+		}
+		p.Row = -1 // just the column, only good for gravity
+		return p, nil
+	}
+	row, err := strconv.Atoi(text[1:])
+	if err != nil {
+		return p, fmt.Errorf("%q should be a column letter and a row number, like b2", text)
+	}
+	p.Row = row - 1
+	if !b.Contains(p) {
+		return p, fmt.Errorf("%s isn't on the board", text)
+	}
+	return p, nil
+}
+
+// ParseMove reads a move for whoever's turn it is in state. Whether it's
+// allowed is still up to State.Apply.
+func ParseMove(s Settings, state State, text string) (Input, error) {
+	in := Input{Player: state.ToMove()}
+	board := state.Grid()
+	text = strings.ToLower(strings.TrimSpace(text))
+
+	if cell, symbol, ok := strings.Cut(text, "="); ok {
+		switch symbol {
+		case "x":
+			in.Symbol = X
+		case "o":
+			in.Symbol = O
+		default:
+			n, err := strconv.Atoi(symbol)
+			if err != nil {
+				return in, fmt.Errorf("%q isn't x, o or a number", symbol)
+			}
+			in.Symbol = n
+		}
+		text = cell
+	}
+
+	if first, second, ok := strings.Cut(text, "-"); ok {
+		if s.Variant != VariantQuantum {
+			return in, errors.New("only quantum moves have two cells")
+		}
+		from, err := ParsePos(board, first)
+		if err != nil {
+			return in, err
+		}
+		other, err := ParsePos(board, second)
+		if err != nil {
+			return in, err
+		}
+		in.Kind, in.Pos, in.Other = MoveSpooky, from, &other
+		return in, nil
+	}
+
+	pos, err := ParsePos(board, text)
+	if err != nil {
+		return in, err
+	}
+	if s.Gravity {
+		row, ok := board.Drop(pos.Col)
+		if !ok {
+			return in, errors.New("that column's full")
+		}
+		pos.Row = row
+	}
+	if pos.Row < 0 {
+		return in, errors.New("that needs a row too, like b2")
+	}
+	in.Pos = pos
+
+	switch g := state.(type) {
+	case *UltimateGame:
+		// the small board and the cell in it
+		in.Board = pos.Row/3*3 + pos.Col/3
+		in.Row, in.Col = pos.Row%3, pos.Col%3
+	case *QuantumGame:
+		if g.Quantum.Pending != -1 {
+			in.Kind = MoveCollapse
+		}
+	}
+	return in, nil
+}
+
+// FormatMove writes in the way ParseMove reads it
+func FormatMove(s Settings, in Input) string {
+	pos := in.Pos
+	if s.Variant == VariantUltimate {
+		pos.Row, pos.Col = UltimateCell(in.Board, in.Row, in.Col)
+	}
+	text := FormatPos(pos)
+	if in.Kind == MoveSpooky && in.Other != nil {
+		text += "-" + FormatPos(*in.Other)
+	}
+	switch {
+	case s.Variant == VariantNumbers:
+		text += fmt.Sprintf("=%d", in.Symbol)
+	case in.Symbol == X && s.PickSymbol():
+		text += "=x"
+	case in.Symbol == O && s.PickSymbol():
+		text += "=o"
+	}
+	return text
+}
@@ -0,0 +1,116 @@
+package game
+
+import "testing"
+
+func TestParsePos(t *testing.T) {
+	b := NewCube(3, 3, 3)
+	tests := []struct {
+		text string
+		want Pos
+		ok   bool
+	}{
+		{"a1", Pos{Row: 0, Col: 0}, true},
+		{"c2", Pos{Row: 1, Col: 2}, true},
+		{" B3 ", Pos{Row: 2, Col: 1}, true},
+		{"2:b2", Pos{Layer: 1, Row: 1, Col: 1}, true},
+		{"b", Pos{Row: -1, Col: 1}, true},
+		{"d1", Pos{}, false},
+		{"a4", Pos{}, false},
+		{"4:a1", Pos{}, false},
+		{"x:a1", Pos{}, false},
+		{"11", Pos{}, false},
+		{"ab", Pos{}, false},
+		{"", Pos{}, false},
+	}
+	for _, tt := range tests {
+		got, err := ParsePos(b, tt.text)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParsePos(%q) error %v, want ok %v", tt.text, err, tt.ok)
+			continue
+		}
+		if tt.ok && got != tt.want {
+			t.Errorf("ParsePos(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+}
+
+// every move a game lists writes out as text that reads back as the same
+// move
+func TestMoveRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules Settings
+		moves []string
+	}{
+		{"classic", Classic, nil},
+		{"gomoku", Settings{Rows: 15, Cols: 15, K: 5}, []string{"h8"}},
+		{"qubic", Qubic, []string{"2:b3"}},
+		{"ultimate", Ultimate, []string{"e5"}},
+		{"ultimate anywhere", Ultimate, nil},
+		{"wild", Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantWild}, []string{"b2=o"}},
+		{"numbers", Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantNumbers}, []string{"b2=5"}},
+		{"quantum", quantumRules, []string{"a1-b1"}},
+		{"quantum collapse", quantumRules, []string{"a1-b1", "b1-a1"}},
+		{"notakto", Settings{Layers: 2, Rows: 3, Cols: 3, K: 3, Variant: VariantNotakto}, []string{"2:a1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := play(t, tt.rules, tt.moves...)
+			for _, in := range state.Moves() {
+				text := FormatMove(tt.rules, in)
+				got, err := ParseMove(tt.rules, state, text)
+				if err != nil {
+					t.Fatalf("%s: %v", text, err)
+				}
+				if !sameMove(got, in) {
+					t.Fatalf("%s read back as %+v, want %+v", text, got, in)
+				}
+			}
+		})
+	}
+}
+
+// sameMove compares what a move does, Other is a pointer
+func sameMove(a, b Input) bool {
+	if (a.Other == nil) != (b.Other == nil) || a.Other != nil && *a.Other != *b.Other {
+		return false
+	}
+	a.Other, b.Other = nil, nil
+	return a == b
+}
+
+func TestParseMove(t *testing.T) {
+	wild := Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantWild}
+	gravity := Settings{Rows: 6, Cols: 7, K: 4, Gravity: true}
+	tests := []struct {
+		name  string
+		rules Settings
+		moves []string
+		text  string
+		want  Input
+		ok    bool
+	}{
+		{"plain", Classic, nil, "c1", Input{Player: 1, Pos: Pos{Row: 0, Col: 2}}, true},
+		{"second player", Classic, []string{"b2"}, "a3", Input{Player: 2, Pos: Pos{Row: 2, Col: 0}}, true},
+		{"symbol", wild, nil, "a1=O", Input{Player: 1, Symbol: O}, true},
+		{"gravity drops", gravity, []string{"d"}, "d", Input{Player: 2, Pos: Pos{Row: 4, Col: 3}}, true},
+		{"gravity ignores the row", gravity, nil, "d1", Input{Player: 1, Pos: Pos{Row: 5, Col: 3}}, true},
+		{"ultimate", Ultimate, nil, "f4", Input{Player: 1, Board: 4, Pos: Pos{Row: 0, Col: 2}}, true},
+		{"no row", Classic, nil, "b", Input{}, false},
+		{"bad symbol", wild, nil, "a1=z", Input{}, false},
+		{"two cells outside quantum", Classic, nil, "a1-b1", Input{}, false},
+		{"full column", Settings{Rows: 3, Cols: 3, K: 3, Gravity: true}, []string{"a", "a", "a"}, "a", Input{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := play(t, tt.rules, tt.moves...)
+			got, err := ParseMove(tt.rules, state, tt.text)
+			if (err == nil) != tt.ok {
+				t.Fatalf("error %v, want ok %v", err, tt.ok)
+			}
+			if tt.ok && got != tt.want {
+				t.Errorf("ParseMove = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
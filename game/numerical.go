@@ -0,0 +1,77 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Numerical tic tac toe: player 1 has the odd numbers 1-9 and player 2 the
+// even ones, each can be used once. The cells hold the numbers instead of
+// who played them, and a full line adding up to 15 wins.
+
+const numericalTarget = 15
+
+// Numbers lists the numbers player hasn't used yet on b
+func Numbers(b Board, player int) []int {
+	used := map[int]bool{}
+	for _, v := range b.Cells {
+		used[v] = true
+	}
+	var left []int
+	for n := 2 - player%2; n <= 9; n += 2 {
+		if !used[n] {
+			left = append(left, n)
+		}
+	}
+	return left
+}
+
+// SumLine finds a full line that adds up to 15
+func SumLine(b Board) (Line, bool) {
+	for row := 0; row < b.Rows; row++ {
+		for col := 0; col < b.Cols; col++ {
+			for _, d := range directions[:4] {
+				line := Line{From: Pos{Row: row, Col: col}, To: Pos{Row: row + 2*d.Row, Col: col + 2*d.Col}}
+				if !b.Contains(line.To) {
+					continue
+				}
+				sum := 0
+				for _, p := range line.Cells() {
+					if b.Get(p) == 0 {
+						sum = -1
+						break
+					}
+					sum += b.Get(p)
+				}
+				if sum == numericalTarget {
+					return line, true
+				}
+			}
+		}
+	}
+	return Line{}, false
+}
+
+// numericalOutcome: whoever makes a line of 15 wins
+func numericalOutcome(b Board, s Settings) string {
+	if _, ok := SumLine(b); ok {
+		return fmt.Sprintf("Player %d", lastMover(b))
+	}
+	if b.Full() {
+		return "CAT"
+	}
+	return ""
+}
+
+// checkNumber makes sure player still has the number they want to play
+func checkNumber(b Board, player, n int) error {
+	for _, left := range Numbers(b, player) {
+		if left == n {
+			return nil
+		}
+	}
+	if player == 1 {
+		return errors.New("pick one of your odd numbers you haven't used")
+	}
+	return errors.New("pick one of your even numbers you haven't used")
+}
@@ -0,0 +1,234 @@
+// Package game holds everything the server and the clients have to agree on:
+// the messages that go over the wire and (eventually) the rules themselves.
+package game
+
+import (
+	"encoding/json"
+)
+
+// Every message on the connection is an Envelope. Type says what kind of
+// message is inside so the other end knows which struct to decode Data into.
+type Envelope struct {
+	Type string
+	Data json.RawMessage `json:",omitempty"`
+}
+
+// message types
+const (
+	TypeRegister    = "register"    // client -> server, Credentials
+	TypeLogin       = "login"       // client -> server, Credentials
+	TypeAuth        = "auth"        // server -> client, AuthResult
+	TypeWelcome     = "welcome"     // server -> client, Welcome
+	TypeMove        = "move"        // client -> server, Input
+	TypeUpdate      = "update"      // server -> client, Update
+	TypeRematch     = "rematch"     // client -> server, no data
+	TypeLeaderboard = "leaderboard" // client -> server no data, server -> client Leaderboard
+	TypeJoin        = "join"        // client -> server, Join
+	TypeLeave       = "leave"       // client -> server, no data
+	TypeChat        = "chat"        // both ways, Message
+
+	TypeTournaments      = "tournaments"       // client -> server no data, server -> client Tournaments
+	TypeCreateTournament = "create_tournament" // client -> server, TournamentRequest with Format
+	TypeJoinTournament   = "join_tournament"   // client -> server, TournamentRequest with ID
+	TypeStartTournament  = "start_tournament"  // client -> server, TournamentRequest with ID
+	TypeMatch            = "match"             // server -> client, Match
+	TypeVariants         = "variants"          // server -> client, Variants, sent after logging in
+	TypeError            = "error"             // server -> client, Error
+)
+
+// Credentials is sent to register a new account or to log in. A client that
+// still has a session token from an earlier login can send just the Token.
+type Credentials struct {
+	Username string `json:",omitempty"`
+	Password string `json:",omitempty"`
+	Token    string `json:",omitempty"`
+	// register the account as a bot, see the Bots part of the README
+	Bot bool `json:",omitempty"`
+}
+
+// AuthResult is the server's answer to a register or login request.
+type AuthResult struct {
+	OK       bool
+	Error    string `json:",omitempty"`
+	Username string `json:",omitempty"`
+	Token    string `json:",omitempty"`
+}
+
+// Join asks for a seat in a room. Room 0 means any quick match game played
+// with Rules, which default to Classic. Watch asks to come in as a spectator
+// instead of taking a seat.
+type Join struct {
+	Room  int
+	Rules Settings `json:",omitempty"`
+	Watch bool     `json:",omitempty"`
+}
+
+// Welcome tells a client which room and seat it got. Spectators get seat 0.
+type Welcome struct {
+	Room   int
+	Rules  Settings
+	Player int
+	Names  []string // one per seat, Names[0] is player 1
+}
+
+// Input is one move, at Pos. In ultimate Board is the small board (0-8) and
+// Row and Col are inside it. Symbol is the mark to put down (X or O) in the
+// variants where players pick one, everywhere else the server uses the
+// player's own. Quantum moves have a Kind, a spooky mark goes in Pos and
+// Other. With gravity only Col counts, the server works out the row.
+type Input struct {
+	Player int
+	Board  int    `json:",omitempty"`
+	Symbol int    `json:",omitempty"`
+	Kind   string `json:",omitempty"`
+	Other  *Pos   `json:",omitempty"`
+	Pos
+}
+
+type Update struct {
+	Player int
+	Rules  Settings
+	Board  Board
+	Turn   int
+	Winner string
+	Names  []string
+	ToMove int // seat whose turn it is
+	// seats taken by bot accounts
+	Bots []bool `json:",omitempty"`
+	// milliseconds the seat to move has to play, only bots have a clock
+	MoveTime int `json:",omitempty"`
+	// the server turned the client's hints off, it does for rated games
+	NoHints bool `json:",omitempty"`
+	// every move of the game so far in order, for going back over it
+	Moves []Input `json:",omitempty"`
+
+	// everything about the game from State.Save, Settings.Load reads it.
+	// Board and Turn are in there too, they're only here to make simple
+	// clients simpler.
+	State json.RawMessage `json:",omitempty"`
+}
+
+// Variants are the variants the server loaded from files, on top of the
+// ones built in
+type Variants struct {
+	List []CustomRules
+}
+
+// Leaderboard is the list of the best rated players, highest rating first.
+type Leaderboard struct {
+	Entries []LeaderboardEntry
+}
+
+type LeaderboardEntry struct {
+	Rank     int
+	Username string
+	Rating   int
+	Games    int
+	Wins     int
+	Losses   int
+	Draws    int
+	Bot      bool `json:",omitempty"`
+}
+
+// Tournament formats
+const (
+	FormatRoundRobin  = "roundrobin"
+	FormatElimination = "elimination"
+)
+
+type TournamentRequest struct {
+	ID     int      `json:",omitempty"`
+	Format string   `json:",omitempty"`
+	Rules  Settings `json:",omitempty"` // board every game is played on
+}
+
+type Tournaments struct {
+	List []TournamentInfo
+}
+
+type TournamentInfo struct {
+	ID        int
+	Name      string
+	Format    string
+	Rules     Settings
+	Creator   string
+	Status    string // "registering", "running" or "finished"
+	Winner    string
+	Players   []string
+	Round     int           // the round being played, starting at 1
+	Pairings  []PairingInfo // matches of the current round
+	Standings []Standing
+}
+
+type PairingInfo struct {
+	Player1 string
+	Player2 string // "" for a bye
+	Result  string // "1-0", "0-1", "draw", "bye" or "" while it's being played
+	Room    int    // room to join to play it, 0 once it's over
+}
+
+// Standing is one row of a tournament table. For round robins Points count 1
+// for a win and half for a draw and TieBreak is the Sonneborn-Berger score.
+// For knockouts Points are the rounds won.
+type Standing struct {
+	Rank     int
+	Username string
+	Points   float64
+	TieBreak float64
+	Wins     int
+	Draws    int
+	Losses   int
+	Status   string
+}
+
+// Match tells a player their next tournament game is ready
+type Match struct {
+	Tournament int
+	Name       string
+	Round      int
+	Room       int
+	Opponent   string
+}
+
+// Message is one line of chat in a room. The client only fills in Text, the
+// server adds who it's From. Notes from the server itself have no From.
+type Message struct {
+	From string `json:",omitempty"`
+	Text string
+}
+
+type Error struct {
+	Text string
+}
+
+// Send wraps v in an Envelope of the given type and writes it to enc.
+// v can be nil for messages that don't carry any data.
+func Send(enc *json.Encoder, typ string, v any) error {
+	env, err := Wrap(typ, v)
+	if err != nil {
+		return err
+	}
+	return enc.Encode(env)
+}
+
+// Wrap puts v in an Envelope of the given type without sending it
+// anywhere, for things that pass messages around without a connection
+func Wrap(typ string, v any) (Envelope, error) {
+	env := Envelope{Type: typ}
+	if v != nil {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return env, err
+		}
+		env.Data = data
+	}
+	return env, nil
+}
+
+// Open decodes the data of an envelope into v.
+func (e Envelope) Open(v any) error {
+	if len(e.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(e.Data, v)
+}
@@ -0,0 +1,231 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// what a puzzle asks for
+const (
+	GoalWin  = "win"  // force a win in In moves
+	GoalDraw = "draw" // find the only moves that don't lose
+)
+
+// the longest win a puzzle can ask for, the search gets slow past it on
+// the bigger boards
+const maxPuzzleMoves = 3
+
+// Puzzle is a position where the player whose turn it is has to force a
+// win, or save a draw. The position is the moves that get there, written
+// like game/notation.go says, so it works for every variant.
+type Puzzle struct {
+	Name  string
+	Rules Settings
+	Moves []string
+	Goal  string
+	In    int `json:",omitempty"` // for GoalWin, how many of their own moves it takes
+}
+
+// LoadPuzzles reads a JSON list of puzzles and checks every one of them
+func LoadPuzzles(path string) ([]Puzzle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var list []Puzzle
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for _, p := range list {
+		if err := p.Check(); err != nil {
+			return nil, fmt.Errorf("puzzle %q: %v", p.Name, err)
+		}
+	}
+	return list, nil
+}
+
+// Start plays the puzzle's moves, the game it starts from and the moves as
+// Inputs
+func (p Puzzle) Start() (State, []Input, error) {
+	state, err := p.Rules.Start()
+	if err != nil {
+		return nil, nil, err
+	}
+	var moves []Input
+	for i, text := range p.Moves {
+		in, err := ParseMove(p.Rules, state, text)
+		if err != nil {
+			return nil, nil, fmt.Errorf("move %d (%s): %v", i+1, text, err)
+		}
+		in.Player = state.ToMove()
+		if err := state.Apply(in); err != nil {
+			return nil, nil, fmt.Errorf("move %d (%s): %v", i+1, text, err)
+		}
+		moves = append(moves, in)
+	}
+	if state.Outcome() != "" {
+		return nil, nil, errors.New("the game's already over")
+	}
+	return state, moves, nil
+}
+
+// Check makes sure the puzzle can be done, and only just: a win in 3 can't
+// also be a win in 2, and saving a draw has to be possible and not a given
+func (p Puzzle) Check() error {
+	if err := p.Rules.Validate(); err != nil {
+		return err
+	}
+	if p.Rules.Seats() != 2 {
+		return errors.New("puzzles are for two players")
+	}
+	state, _, err := p.Start()
+	if err != nil {
+		return err
+	}
+	switch p.Goal {
+	case GoalWin:
+		if p.In < 1 || p.In > maxPuzzleMoves {
+			return fmt.Errorf("In has to be from 1 to %d", maxPuzzleMoves)
+		}
+		if _, ok := ForcedWin(p.Rules, state, p.In); !ok {
+			return fmt.Errorf("there's no win in %d", p.In)
+		}
+		if _, ok := ForcedWin(p.Rules, state, p.In-1); ok {
+			return fmt.Errorf("there's a win in %d already", p.In-1)
+		}
+	case GoalDraw:
+		solver, err := NewSolver(p.Rules)
+		if err != nil {
+			return err
+		}
+		if v := solver.Solve(state); v.Result != 0 {
+			return fmt.Errorf("it's a %s, not a draw", v)
+		}
+		losing := false
+		for _, mv := range solver.Analyze(state) {
+			losing = losing || mv.Value.Result < 0
+		}
+		if !losing {
+			return errors.New("every move draws, there's nothing to find")
+		}
+	default:
+		return fmt.Errorf("Goal has to be %q or %q", GoalWin, GoalDraw)
+	}
+	return nil
+}
+
+// Keeps is whether playing in still gets the puzzle done, with left moves
+// to go before it (counting in)
+func (p Puzzle) Keeps(state State, in Input, left int) bool {
+	me := state.ToMove()
+	next, ok := After(p.Rules, state, in)
+	if !ok {
+		return false
+	}
+	if p.Goal == GoalDraw {
+		solver, err := NewSolver(p.Rules)
+		if err != nil {
+			return false
+		}
+		if next.Outcome() != "" {
+			return result(next, me).Result >= 0
+		}
+		v := solver.Solve(next)
+		if next.ToMove() != me {
+			v.Result = -v.Result
+		}
+		return v.Result >= 0
+	}
+	return next.Outcome() == fmt.Sprintf("Player %d", me) || (next.Outcome() == "" && forced(p.Rules, next, me, left-1))
+}
+
+// Reply is the other player's answer, the one that holds out longest
+func (p Puzzle) Reply(state State, left int) (Input, bool) {
+	if p.Goal == GoalDraw {
+		solver, err := NewSolver(p.Rules)
+		if err != nil {
+			return Input{}, false
+		}
+		return solver.Best(state)
+	}
+	// the player being attacked is to move, the attacker is the other seat
+	attacker := 3 - state.ToMove()
+	var best Input
+	longest := -1
+	for _, in := range state.Moves() {
+		next, ok := After(p.Rules, state, in)
+		if !ok {
+			continue
+		}
+		// how many moves the win takes after this, left+1 if it's gone
+		n := left + 1
+		switch next.Outcome() {
+		case fmt.Sprintf("Player %d", attacker):
+			n = 0 // handing it over is as bad as it gets
+		case "":
+			for k := 1; k <= left; k++ {
+				if forced(p.Rules, next, attacker, k) {
+					n = k
+					break
+				}
+			}
+		}
+		if n > longest {
+			best, longest = in, n
+		}
+	}
+	return best, longest >= 0
+}
+
+// ForcedWin is a move that wins for whoever's turn it is within n of their
+// own moves whatever the other player does, if there's one
+func ForcedWin(rules Settings, state State, n int) (Input, bool) {
+	me := state.ToMove()
+	if n < 1 {
+		return Input{}, false
+	}
+	for _, in := range state.Moves() {
+		next, ok := After(rules, state, in)
+		if !ok {
+			continue
+		}
+		if next.Outcome() == fmt.Sprintf("Player %d", me) {
+			return in, true
+		}
+		if next.Outcome() == "" && forced(rules, next, me, n-1) {
+			return in, true
+		}
+	}
+	return Input{}, false
+}
+
+// forced is whether me wins within n more of their own moves from state,
+// whatever the other player does
+func forced(rules Settings, state State, me, n int) bool {
+	if state.ToMove() == me {
+		_, ok := ForcedWin(rules, state, n)
+		return ok
+	}
+	if n < 1 {
+		return false
+	}
+	for _, in := range state.Moves() {
+		next, ok := After(rules, state, in)
+		if !ok {
+			continue
+		}
+		switch next.Outcome() {
+		case fmt.Sprintf("Player %d", me):
+			continue // they handed it over
+		case "":
+			if !forced(rules, next, me, n) {
+				return false
+			}
+		default:
+			return false // a draw or their win
+		}
+	}
+	return true
+}
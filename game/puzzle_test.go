@@ -0,0 +1,141 @@
+package game
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPuzzleCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Puzzle
+		ok   bool
+	}{
+		{"win in 1", Puzzle{Rules: Classic, Moves: []string{"a1", "a2", "b1", "b2"}, Goal: GoalWin, In: 1}, true},
+		{"win in 3", Puzzle{Rules: Classic, Moves: []string{"a1", "b1"}, Goal: GoalWin, In: 3}, true},
+		{"draw", Puzzle{Rules: Classic, Moves: []string{"b2", "c3", "a2"}, Goal: GoalDraw}, true},
+		{"quicker win there", Puzzle{Rules: Classic, Moves: []string{"a1", "a2", "b1", "b2"}, Goal: GoalWin, In: 2}, false},
+		{"no win", Puzzle{Rules: Classic, Moves: []string{"b2", "c3", "a2"}, Goal: GoalWin, In: 1}, false},
+		{"in 0", Puzzle{Rules: Classic, Moves: []string{"a1", "a2", "b1", "b2"}, Goal: GoalWin}, false},
+		{"in 4", Puzzle{Rules: Classic, Moves: []string{"a1"}, Goal: GoalWin, In: 4}, false},
+		{"no goal", Puzzle{Rules: Classic, Moves: []string{"a1"}}, false},
+		{"already over", Puzzle{Rules: Classic, Moves: []string{"a1", "a2", "b1", "b2", "c1"}, Goal: GoalWin, In: 1}, false},
+		{"bad move", Puzzle{Rules: Classic, Moves: []string{"a1", "a1"}, Goal: GoalWin, In: 1}, false},
+		{"lost, not drawn", Puzzle{Rules: Classic, Moves: []string{"a1", "b1"}, Goal: GoalDraw}, false},
+		{"every move draws", Puzzle{Rules: Classic, Goal: GoalDraw}, false},
+		{"draw too big to solve", Puzzle{Rules: Settings{Rows: 4, Cols: 4, K: 4}, Moves: []string{"a1"}, Goal: GoalDraw}, false},
+		{"three players", Puzzle{Rules: Settings{Rows: 6, Cols: 6, K: 4, Players: 3}, Goal: GoalWin, In: 1}, false},
+		{"bad rules", Puzzle{Rules: Settings{Rows: 2, Cols: 2, K: 2}, Goal: GoalWin, In: 1}, false},
+	}
+	for _, tt := range tests {
+		err := tt.p.Check()
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: Check = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+// the puzzles the client ships with all load, and the win ones can be won
+// in the moves they say when the other side defends as well as it can
+func TestShippedPuzzles(t *testing.T) {
+	list, err := LoadPuzzles(filepath.Join("..", "puzzles.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) == 0 {
+		t.Fatal("no puzzles")
+	}
+	for _, p := range list {
+		if p.Goal != GoalWin {
+			continue
+		}
+		t.Run(p.Name, func(t *testing.T) {
+			state, _, err := p.Start()
+			if err != nil {
+				t.Fatal(err)
+			}
+			me := state.ToMove()
+			for left := p.In; left > 0; left-- {
+				in, ok := ForcedWin(p.Rules, state, left)
+				if !ok {
+					t.Fatalf("no win in %d", left)
+				}
+				if !p.Keeps(state, in, left) {
+					t.Fatalf("the winning move %s doesn't keep the puzzle going", FormatMove(p.Rules, in))
+				}
+				state, _ = After(p.Rules, state, in)
+				if state.Outcome() != "" {
+					break
+				}
+				reply, ok := p.Reply(state, left-1)
+				if !ok {
+					t.Fatal("no reply")
+				}
+				state, _ = After(p.Rules, state, reply)
+			}
+			if got, want := state.Outcome(), fmt.Sprintf("Player %d", me); got != want {
+				t.Errorf("outcome %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestPuzzleKeeps(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Puzzle
+		move string
+		want bool
+	}{
+		{"winning move", Puzzle{Rules: Classic, Moves: []string{"a1", "a2", "b1", "b2"}, Goal: GoalWin, In: 1}, "c1", true},
+		{"blocks instead", Puzzle{Rules: Classic, Moves: []string{"a1", "a2", "b1", "b2"}, Goal: GoalWin, In: 1}, "c2", false},
+		{"fork", Puzzle{Rules: Classic, Moves: []string{"b1", "c1", "c3", "c2"}, Goal: GoalWin, In: 2}, "b2", true},
+		{"no fork", Puzzle{Rules: Classic, Moves: []string{"b1", "c1", "c3", "c2"}, Goal: GoalWin, In: 2}, "a3", false},
+		{"block", Puzzle{Rules: Classic, Moves: []string{"b2", "c3", "a2"}, Goal: GoalDraw}, "c2", true},
+		{"doesn't block", Puzzle{Rules: Classic, Moves: []string{"b2", "c3", "a2"}, Goal: GoalDraw}, "a1", false},
+		{"taken cell", Puzzle{Rules: Classic, Moves: []string{"b2", "c3", "a2"}, Goal: GoalDraw}, "b2", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.p.Check(); err != nil {
+				t.Fatal(err)
+			}
+			state, _, err := tt.p.Start()
+			if err != nil {
+				t.Fatal(err)
+			}
+			board := state.Grid()
+			p, err := ParsePos(board, tt.move)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tt.p.Keeps(state, Input{Player: state.ToMove(), Pos: p}, tt.p.In); got != tt.want {
+				t.Errorf("Keeps(%s) = %v, want %v", tt.move, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPuzzlesErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name, text string
+	}{
+		{"not json", "puzzles"},
+		{"bad puzzle", `[{"Name": "nope", "Rules": {"Rows": 3, "Cols": 3, "K": 3}, "Goal": "win", "In": 1}]`},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name+".json")
+		if err := os.WriteFile(path, []byte(tt.text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadPuzzles(path); err == nil {
+			t.Errorf("%s loaded", tt.name)
+		}
+	}
+	if _, err := LoadPuzzles(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("a missing file loaded")
+	}
+}
@@ -0,0 +1,258 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Quantum tic tac toe: every move is a spooky mark put in two cells at
+// once. Spooky marks that share a cell are entangled, and when they make a
+// cycle the player who didn't close it picks which of its two cells the
+// closing mark ends up in. That collapses every mark entangled with it into
+// a classical mark in one cell. The Board only holds the classical marks,
+// QuantumState holds the rest.
+
+// move kinds in quantum games
+const (
+	MoveSpooky   = "spooky"   // put a spooky mark in Pos and Other
+	MoveCollapse = "collapse" // pick Pos as the cell the pending mark collapses into
+)
+
+// Spooky is one spooky mark, Move is the turn it was played on which
+// doubles as its subscript
+type Spooky struct {
+	Player int
+	Move   int
+	Cells  [2]Pos
+}
+
+type QuantumState struct {
+	Marks []Spooky // spooky marks that haven't collapsed yet
+	// turn each classical mark was played on, cell by cell, for breaking
+	// ties when both players get a line from the same collapse
+	Moves []int
+	// index into Marks of the mark that closed a cycle and is waiting for
+	// its collapse to be picked, -1 when there isn't one
+	Pending int
+}
+
+func NewQuantumState() QuantumState {
+	return QuantumState{Moves: make([]int, 9), Pending: -1}
+}
+
+// Play makes a move for player on turn. It says whether the turn is over,
+// picking a collapse doesn't end it, you still get your own move after.
+func (q *QuantumState) Play(b Board, in Input, player, turn int) (bool, error) {
+	if q.Pending != -1 && in.Kind != MoveCollapse {
+		return false, errors.New("pick where the mark collapses first")
+	}
+
+	switch in.Kind {
+	case MoveCollapse:
+		if q.Pending == -1 {
+			return false, errors.New("there's nothing to collapse")
+		}
+		mark := q.Marks[q.Pending]
+		if in.Pos != mark.Cells[0] && in.Pos != mark.Cells[1] {
+			return false, errors.New("the mark can only collapse into one of its two cells")
+		}
+		q.collapse(b, q.Pending, in.Pos)
+		q.Pending = -1
+		return false, nil
+
+	case MoveSpooky:
+		if in.Other == nil || in.Pos == *in.Other {
+			return false, errors.New("a spooky mark needs two different cells")
+		}
+		for _, p := range []Pos{in.Pos, *in.Other} {
+			if !b.Contains(p) || b.Get(p) != 0 {
+				return false, errors.New("spooky marks can only go in cells without a classical mark")
+			}
+		}
+		// if the two cells are already connected the new mark closes a cycle
+		cycle := q.connected(in.Pos, *in.Other)
+		q.Marks = append(q.Marks, Spooky{Player: player, Move: turn, Cells: [2]Pos{in.Pos, *in.Other}})
+		if cycle {
+			q.Pending = len(q.Marks) - 1
+		}
+		return true, nil
+
+	default:
+		// a plain classical mark is only allowed in the very last free cell
+		free := q.free(b)
+		if len(free) != 1 || in.Pos != free[0] {
+			return false, errors.New("place a spooky mark in two cells")
+		}
+		b.Put(in.Pos, player)
+		q.Moves[in.Pos.Row*b.Cols+in.Pos.Col] = turn
+		return true, nil
+	}
+}
+
+// free lists the cells without a classical mark
+func (q *QuantumState) free(b Board) []Pos {
+	var cells []Pos
+	for row := 0; row < b.Rows; row++ {
+		for col := 0; col < b.Cols; col++ {
+			if b.At(row, col) == 0 {
+				cells = append(cells, Pos{Row: row, Col: col})
+			}
+		}
+	}
+	return cells
+}
+
+// connected says whether spooky marks link cell a to cell b
+func (q *QuantumState) connected(a, b Pos) bool {
+	seen := map[Pos]bool{a: true}
+	queue := []Pos{a}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if p == b {
+			return true
+		}
+		for _, m := range q.Marks {
+			for i, c := range m.Cells {
+				other := m.Cells[1-i]
+				if c == p && !seen[other] {
+					seen[other] = true
+					queue = append(queue, other)
+				}
+			}
+		}
+	}
+	return false
+}
+
+// collapse makes mark i classical in cell at. Every other mark in that
+// cell then has to go to its other cell, and so on down the chain.
+func (q *QuantumState) collapse(b Board, i int, at Pos) {
+	type step struct {
+		mark Spooky
+		at   Pos
+	}
+	queue := []step{{q.Marks[i], at}}
+	q.Marks = append(q.Marks[:i:i], q.Marks[i+1:]...)
+
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		b.Put(s.at, s.mark.Player)
+		q.Moves[s.at.Row*b.Cols+s.at.Col] = s.mark.Move
+
+		// whatever else was in that cell gets pushed out
+		left := q.Marks[:0]
+		for _, m := range q.Marks {
+			switch s.at {
+			case m.Cells[0]:
+				queue = append(queue, step{m, m.Cells[1]})
+			case m.Cells[1]:
+				queue = append(queue, step{m, m.Cells[0]})
+			default:
+				left = append(left, m)
+			}
+		}
+		q.Marks = left
+	}
+}
+
+// Outcome is who won from the classical marks on b. If one collapse gives
+// both players a line, the line whose newest mark is older wins.
+func (q QuantumState) Outcome(b Board) string {
+	if q.Pending != -1 {
+		return ""
+	}
+
+	best, winner := 0, 0
+	for row := 0; row < b.Rows; row++ {
+		for col := 0; col < b.Cols; col++ {
+			for _, d := range directions[:4] {
+				line := Line{From: Pos{Row: row, Col: col}, To: Pos{Row: row + 2*d.Row, Col: col + 2*d.Col}}
+				if !b.Contains(line.To) {
+					continue
+				}
+				player, newest := b.Get(line.From), 0
+				for _, p := range line.Cells() {
+					if b.Get(p) != player {
+						player = 0
+					}
+					newest = max(newest, q.Moves[p.Row*b.Cols+p.Col])
+				}
+				if player > 0 && (winner == 0 || newest < best) {
+					best, winner = newest, player
+				}
+			}
+		}
+	}
+	if winner != 0 {
+		return fmt.Sprintf("Player %d", winner)
+	}
+	if len(q.free(b)) == 0 {
+		return "CAT"
+	}
+	return ""
+}
+
+// quantum is the Variant
+type quantum struct{}
+
+func (quantum) Validate(s Settings) error {
+	return validate3x3(s)
+}
+
+func (quantum) Start(s Settings) State {
+	return &QuantumGame{PlainGame: PlainGame{Board: s.NewBoard(), Turn: 1, rules: s}, Quantum: NewQuantumState()}
+}
+
+func (quantum) Load(s Settings, data []byte) (State, error) {
+	g := &QuantumGame{PlainGame: PlainGame{rules: s}}
+	err := json.Unmarshal(data, g)
+	return g, err
+}
+
+// QuantumGame is a game of quantum, the classical marks are on the board
+type QuantumGame struct {
+	PlainGame
+	Quantum QuantumState
+}
+
+func (g *QuantumGame) Apply(in Input) error {
+	done, err := g.Quantum.Play(g.Board, in, in.Player, g.Turn)
+	if done {
+		g.Turn++
+	}
+	return err
+}
+
+func (g *QuantumGame) Outcome() string {
+	return g.Quantum.Outcome(g.Board)
+}
+
+func (g *QuantumGame) Moves() []Input {
+	player := g.ToMove()
+	q := g.Quantum
+	if q.Pending != -1 {
+		cells := q.Marks[q.Pending].Cells
+		return []Input{
+			{Player: player, Kind: MoveCollapse, Pos: cells[0]},
+			{Player: player, Kind: MoveCollapse, Pos: cells[1]},
+		}
+	}
+	free := q.free(g.Board)
+	if len(free) == 1 {
+		return []Input{{Player: player, Pos: free[0]}}
+	}
+	var moves []Input
+	for i := range free {
+		for j := i + 1; j < len(free); j++ {
+			moves = append(moves, Input{Player: player, Kind: MoveSpooky, Pos: free[i], Other: &free[j]})
+		}
+	}
+	return moves
+}
+
+func (g *QuantumGame) Save() ([]byte, error) {
+	return json.Marshal(g)
+}
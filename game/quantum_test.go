@@ -0,0 +1,244 @@
+package game
+
+import "testing"
+
+var quantumRules = Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantQuantum}
+
+// pos reads a cell like b2 on a 3x3 board
+func pos(t *testing.T, text string) Pos {
+	t.Helper()
+	p, err := ParsePos(NewBoard(3, 3), text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestConnected(t *testing.T) {
+	q := NewQuantumState()
+	for _, cells := range [][2]string{{"a1", "b1"}, {"b1", "c1"}, {"a3", "b3"}} {
+		q.Marks = append(q.Marks, Spooky{Player: 1, Cells: [2]Pos{pos(t, cells[0]), pos(t, cells[1])}})
+	}
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"a1", "b1", true},
+		{"a1", "c1", true}, // through b1
+		{"c1", "a1", true},
+		{"a3", "b3", true},
+		{"a1", "a3", false},
+		{"b2", "c3", false},
+	}
+	for _, tt := range tests {
+		if got := q.connected(pos(t, tt.a), pos(t, tt.b)); got != tt.want {
+			t.Errorf("connected(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// closing a cycle leaves a mark pending, and picking its cell collapses
+// everything entangled with it
+func TestCollapse(t *testing.T) {
+	tests := []struct {
+		name  string
+		moves []string // the last one closes a cycle
+		at    string
+		want  map[string]int // classical marks afterwards
+		left  int            // spooky marks that didn't collapse
+	}{
+		{
+			name:  "cycle of three, closing cell",
+			moves: []string{"a1-b1", "b1-c1", "c1-a1"},
+			at:    "a1",
+			want:  map[string]int{"a1": 1, "b1": 1, "c1": 2},
+		},
+		{
+			name:  "cycle of three, other cell",
+			moves: []string{"a1-b1", "b1-c1", "c1-a1"},
+			at:    "c1",
+			want:  map[string]int{"a1": 1, "b1": 2, "c1": 1},
+		},
+		{
+			name:  "cascade down a tail",
+			moves: []string{"a1-b1", "a2-a1", "b1-c1", "c1-a1"},
+			at:    "a1",
+			want:  map[string]int{"a1": 2, "a2": 2, "b1": 1, "c1": 1},
+		},
+		{
+			name:  "two marks in the same cells",
+			moves: []string{"a1-b1", "b3-c3", "a1-b1"},
+			at:    "b1",
+			want:  map[string]int{"a1": 1, "b1": 1},
+			left:  1, // b3-c3 isn't entangled
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := play(t, quantumRules, tt.moves...).(*QuantumGame)
+			if g.Quantum.Pending == -1 {
+				t.Fatal("the last move didn't close a cycle")
+			}
+			toMove, turn := g.ToMove(), g.CurrentTurn()
+			if err := move(g, quantumRules, tt.at); err != nil {
+				t.Fatal(err)
+			}
+			if g.Quantum.Pending != -1 {
+				t.Error("still pending after the collapse")
+			}
+			if g.ToMove() != toMove || g.CurrentTurn() != turn {
+				t.Error("picking the collapse ended the turn")
+			}
+			marks := 0
+			for i, v := range g.Board.Cells {
+				p := Pos{Row: i / 3, Col: i % 3}
+				if v != tt.want[FormatPos(p)] {
+					t.Errorf("%s = %d, want %d", FormatPos(p), v, tt.want[FormatPos(p)])
+				}
+				if v != 0 {
+					marks++
+					if g.Quantum.Moves[i] == 0 {
+						t.Errorf("%s has no move number", FormatPos(p))
+					}
+				}
+			}
+			if marks != len(tt.want) {
+				t.Errorf("%d classical marks, want %d", marks, len(tt.want))
+			}
+			if len(g.Quantum.Marks) != tt.left {
+				t.Errorf("%d spooky marks left, want %d", len(g.Quantum.Marks), tt.left)
+			}
+		})
+	}
+}
+
+func TestQuantumErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		moves []string
+		bad   string
+	}{
+		{"same cell twice", nil, "a1-a1"},
+		{"classical move", nil, "a1"},
+		{"spooky while pending", []string{"a1-b1", "a1-b1"}, "a2-b2"},
+		{"collapse somewhere else", []string{"a1-b1", "a1-b1"}, "c3"},
+		{"on a classical mark", []string{"a1-b1", "a1-b1", "a1"}, "a1-a2"},
+		{"off the board", nil, "a1-d1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := play(t, quantumRules, tt.moves...)
+			if err := move(state, quantumRules, tt.bad); err == nil {
+				t.Errorf("%s was allowed", tt.bad)
+			}
+		})
+	}
+
+	// nothing pending, a collapse makes no sense
+	q := NewQuantumState()
+	if _, err := q.Play(NewBoard(3, 3), Input{Kind: MoveCollapse}, 1, 1); err == nil {
+		t.Error("a collapse with nothing pending was allowed")
+	}
+}
+
+// with one cell left it gets a plain classical mark
+func TestLastCell(t *testing.T) {
+	b := NewBoard(3, 3)
+	copy(b.Cells, []int{1, 2, 1, 1, 2, 2, 2, 1, 0})
+	q := NewQuantumState()
+	if _, err := q.Play(b, Input{Pos: pos(t, "b2")}, 1, 9); err == nil {
+		t.Error("a classical mark on a taken cell was allowed")
+	}
+	done, err := q.Play(b, Input{Pos: pos(t, "c3")}, 1, 9)
+	if err != nil || !done {
+		t.Fatalf("Play = %v, %v", done, err)
+	}
+	if b.At(2, 2) != 1 || q.Moves[8] != 9 {
+		t.Errorf("c3 = %d on move %d", b.At(2, 2), q.Moves[8])
+	}
+}
+
+func TestQuantumOutcome(t *testing.T) {
+	tests := []struct {
+		name    string
+		cells   []int
+		moves   []int // the turn each cell was played on
+		pending bool
+		want    string
+	}{
+		{
+			name:  "nothing",
+			cells: []int{1, 0, 0, 0, 0, 0, 0, 0, 0},
+			moves: []int{1, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name:  "one line",
+			cells: []int{2, 2, 2, 1, 1, 0, 0, 0, 0},
+			moves: []int{2, 4, 6, 1, 3, 0, 0, 0, 0},
+			want:  "Player 2",
+		},
+		{
+			name:  "both, x's line is older",
+			cells: []int{1, 1, 1, 2, 2, 2, 0, 0, 0},
+			moves: []int{1, 3, 5, 2, 4, 6, 0, 0, 0},
+			want:  "Player 1",
+		},
+		{
+			name:  "both, o's line is older",
+			cells: []int{1, 1, 1, 2, 2, 2, 0, 0, 0},
+			moves: []int{1, 3, 7, 2, 4, 6, 0, 0, 0},
+			want:  "Player 2",
+		},
+		{
+			name:    "waiting on a collapse",
+			cells:   []int{1, 1, 1, 0, 0, 0, 0, 0, 0},
+			moves:   []int{1, 3, 5, 0, 0, 0, 0, 0, 0},
+			pending: true,
+		},
+		{
+			name:  "full",
+			cells: []int{1, 2, 1, 1, 2, 2, 2, 1, 1},
+			moves: []int{1, 2, 3, 4, 5, 6, 7, 8, 9},
+			want:  "CAT",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBoard(3, 3)
+			copy(b.Cells, tt.cells)
+			q := QuantumState{Moves: tt.moves, Pending: -1}
+			if tt.pending {
+				q.Pending = 0
+			}
+			if got := q.Outcome(b); got != tt.want {
+				t.Errorf("Outcome = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuantumMoves(t *testing.T) {
+	tests := []struct {
+		name  string
+		moves []string
+		want  int
+	}{
+		{"start", nil, 36}, // every pair of 9 cells
+		{"pending", []string{"a1-b1", "a1-b1"}, 2},
+		{"after a collapse", []string{"a1-b1", "a1-b1", "a1"}, 21},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := play(t, quantumRules, tt.moves...)
+			moves := state.Moves()
+			if len(moves) != tt.want {
+				t.Fatalf("%d moves, want %d", len(moves), tt.want)
+			}
+			for _, in := range moves {
+				if _, ok := After(quantumRules, state, in); !ok {
+					t.Errorf("%s isn't allowed", FormatMove(quantumRules, in))
+				}
+			}
+		})
+	}
+}
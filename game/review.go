@@ -0,0 +1,77 @@
+package game
+
+import "fmt"
+
+// what the review calls a move
+const (
+	LabelBest       = "best"       // as good as perfect play
+	LabelInaccuracy = "inaccuracy" // same result, but it takes longer to win or comes sooner to lose
+	LabelBlunder    = "blunder"    // a worse result, like a draw thrown into a loss
+)
+
+// Annotation is what the review says about one move of a game
+type Annotation struct {
+	Move   Input
+	Label  string
+	Before Value // what the game was worth for the player before they moved
+	After  Value // and after, still for them
+	Best   Input // a move perfect play would have made
+}
+
+// Changed is whether the move changed how the game ends
+func (a Annotation) Changed() bool {
+	return a.Before.Result != a.After.Result
+}
+
+// Replay plays moves from the start, the game before each move and after
+// the last one
+func Replay(rules Settings, moves []Input) ([]State, error) {
+	state, err := rules.Start()
+	if err != nil {
+		return nil, err
+	}
+	states := []State{state}
+	for i, in := range moves {
+		next, ok := After(rules, state, in)
+		if !ok {
+			return nil, fmt.Errorf("move %d (%s) can't be played", i+1, FormatMove(rules, in))
+		}
+		states = append(states, next)
+		state = next
+	}
+	return states, nil
+}
+
+// Review goes through a game and compares every move to perfect play
+func (s *Solver) Review(moves []Input) ([]Annotation, error) {
+	states, err := Replay(s.rules, moves)
+	if err != nil {
+		return nil, err
+	}
+	var list []Annotation
+	for i, in := range moves {
+		a := Annotation{Move: in, Label: LabelBest}
+		// the move as it was sent can differ from the one in Moves (a
+		// gravity move without its row), the game it made doesn't
+		played, _ := states[i+1].Save()
+		found := false
+		for _, mv := range s.Analyze(states[i]) {
+			if !found || mv.Value.Better(a.Before) {
+				a.Before, a.Best, found = mv.Value, mv.Move, true
+			}
+			if next, ok := After(s.rules, states[i], mv.Move); ok {
+				if data, _ := next.Save(); string(data) == string(played) {
+					a.After = mv.Value
+				}
+			}
+		}
+		switch {
+		case a.Changed():
+			a.Label = LabelBlunder
+		case a.Before.Result != 0 && a.Before.Better(a.After):
+			a.Label = LabelInaccuracy // every drawing move is as good as the next
+		}
+		list = append(list, a)
+	}
+	return list, nil
+}
@@ -0,0 +1,120 @@
+package game
+
+import "testing"
+
+// moves reads a game written out the way ParseMove reads it
+func moves(t *testing.T, rules Settings, texts ...string) []Input {
+	t.Helper()
+	state, err := rules.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list []Input
+	for _, text := range texts {
+		in, err := ParseMove(rules, state, text)
+		if err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+		if err := state.Apply(in); err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+		list = append(list, in)
+	}
+	return list
+}
+
+func TestReview(t *testing.T) {
+	tests := []struct {
+		name   string
+		moves  []string
+		labels []string
+	}{
+		{"good start", []string{"b2", "a1"}, []string{LabelBest, LabelBest}},
+		{"edge against the middle", []string{"b2", "b1"}, []string{LabelBest, LabelBlunder}},
+		{"anything but the middle", []string{"a1", "a2"}, []string{LabelBest, LabelBlunder}},
+		{
+			"missed block",
+			[]string{"a1", "b2", "b1", "a3"},
+			[]string{LabelBest, LabelBest, LabelBest, LabelBlunder},
+		},
+		{
+			"slow win",
+			[]string{"a1", "b1", "c1", "a2", "b2", "c2", "b3"},
+			[]string{LabelBest, LabelBlunder, LabelBlunder, LabelBlunder, LabelBest, LabelBest, LabelInaccuracy},
+		},
+	}
+	s, err := NewSolver(Classic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := s.Review(moves(t, Classic, tt.moves...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(list) != len(tt.labels) {
+				t.Fatalf("%d annotations, want %d", len(list), len(tt.labels))
+			}
+			for i, a := range list {
+				if a.Label != tt.labels[i] {
+					t.Errorf("%s is %s (%v to %v), want %s", tt.moves[i], a.Label, a.Before, a.After, tt.labels[i])
+				}
+				if (a.Label == LabelBlunder) != a.Changed() {
+					t.Errorf("%s: %s but Changed is %v", tt.moves[i], a.Label, a.Changed())
+				}
+				if a.Label == LabelBest && a.Before != a.After && a.Before.Result != 0 {
+					t.Errorf("%s is best but went from %v to %v", tt.moves[i], a.Before, a.After)
+				}
+			}
+		})
+	}
+}
+
+// the blunder from a draw into a loss, with what should have been played
+func TestReviewBlunder(t *testing.T) {
+	s, err := NewSolver(Classic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := s.Review(moves(t, Classic, "a1", "b2", "b1", "a3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := list[3]
+	if a.Before != (Value{Result: 0, Moves: 6}) || a.After.Result != -1 {
+		t.Errorf("went from %v to %v", a.Before, a.After)
+	}
+	if got := FormatMove(Classic, a.Best); got != "c1" {
+		t.Errorf("best move %s, want c1", got)
+	}
+}
+
+// a gravity move sent without its row is still found
+func TestReviewGravity(t *testing.T) {
+	rules := Settings{Rows: 3, Cols: 3, K: 3, Gravity: true}
+	s, err := NewSolver(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := s.Review([]Input{{Player: 1, Pos: Pos{Row: 0, Col: 1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list[0].After != list[0].Before {
+		t.Errorf("went from %v to %v", list[0].Before, list[0].After)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	states, err := Replay(Classic, moves(t, Classic, "a1", "b2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 3 || states[0].CurrentTurn() != 1 || states[2].Grid().At(1, 1) != 2 {
+		t.Errorf("%d states", len(states))
+	}
+	if _, err := Replay(Classic, []Input{{Player: 1}, {Player: 2}}); err == nil {
+		t.Error("replayed a move on a taken cell")
+	}
+}
@@ -0,0 +1,119 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The outcomes of the variants that only need the board. They return
+// "Player N" for the winner, "CAT" for a draw or "" while the game is still
+// going.
+
+// Marks for the two symbols. In most variants a player always plays their
+// own one, player 1 is X and player 2 is O.
+const (
+	X = 1
+	O = 2
+)
+
+// PickSymbol says whether players choose what to put down on every move
+// instead of always playing their own mark: X or O, or a number in numbers
+func (s Settings) PickSymbol() bool {
+	return s.Variant == VariantWild || s.Variant == VariantOrder || s.Variant == VariantNumbers
+}
+
+// Symbol is what goes in the cell when player moves with symbol, or why
+// they can't play it
+func (s Settings) Symbol(b Board, player, symbol int) (int, error) {
+	switch {
+	case s.Variant == VariantNumbers:
+		return symbol, checkNumber(b, player, symbol)
+	case !s.PickSymbol():
+		return player, nil
+	case symbol != X && symbol != O:
+		return 0, errors.New("pick X or O")
+	}
+	return symbol, nil
+}
+
+// Check says why a move at p isn't allowed, on top of the cell having to be
+// on the board and empty
+func (s Settings) Check(b Board, p Pos) error {
+	if s.Variant == VariantNotakto && Dead(b.Layer(p.Layer)) {
+		return errors.New("that board is already dead")
+	}
+	return nil
+}
+
+// normalOutcome is plain k in a row
+func normalOutcome(b Board, s Settings) string {
+	return Outcome(b, s.K)
+}
+
+// misereOutcome turns k in a row around, whoever makes it loses
+func misereOutcome(b Board, s Settings) string {
+	if line, ok := b.FindLine(s.K); ok {
+		return fmt.Sprintf("Player %d", 3-line.Player)
+	}
+	if b.Full() {
+		return "CAT"
+	}
+	return ""
+}
+
+// notaktoOutcome: everybody plays X, each layer of b is its own board and a
+// board with 3 in a row is dead. Whoever kills the last board loses. There
+// are no draws, a full board always has a line.
+func notaktoOutcome(b Board, s Settings) string {
+	for layer := 0; layer < b.Layers; layer++ {
+		if !Dead(b.Layer(layer)) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("Player %d", 3-lastMover(b))
+}
+
+// wildOutcome: k in a row of either symbol wins for whoever made it
+func wildOutcome(b Board, s Settings) string {
+	if _, ok := b.FindLine(s.K); ok {
+		return fmt.Sprintf("Player %d", lastMover(b))
+	}
+	if b.Full() {
+		return "CAT"
+	}
+	return ""
+}
+
+// orderOutcome: order (player 1) wins with k in a row of either symbol,
+// chaos (player 2) wins by filling the board without one
+func orderOutcome(b Board, s Settings) string {
+	if _, ok := b.FindLine(s.K); ok {
+		return "Player 1"
+	}
+	if b.Full() {
+		return "Player 2"
+	}
+	return ""
+}
+
+// lastMover works out who made the last move from how many marks there are.
+// Player 1 always starts so an odd number means it was them.
+func lastMover(b Board) int {
+	marks := 0
+	for _, v := range b.Cells {
+		if v != 0 {
+			marks++
+		}
+	}
+	if marks%2 == 1 {
+		return 1
+	}
+	return 2
+}
+
+// Dead says whether a notakto board has 3 in a row. Who played the marks
+// doesn't matter, they're all X.
+func Dead(b Board) bool {
+	_, ok := b.Marks().FindLine(3)
+	return ok
+}
@@ -0,0 +1,222 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// a win is worth searchWin less how many moves it takes, so a quick win
+// beats a slow one and a slow loss beats a quick one
+const searchWin = 1000
+
+// how many positions a search looks at for one move and how long it takes,
+// at most. It goes a move deeper at a time until one of them runs out, a
+// 3x3 board gets searched to the end.
+const (
+	searchNodes = 5000
+	searchTime  = 250 * time.Millisecond
+)
+
+// Search is a minimax player that works for every variant, since all it
+// uses is State. With more than two players it assumes everybody else
+// plays against it. Positions it has scored are remembered, so it gets
+// faster the more games it plays with the same rules.
+type Search struct {
+	Rules Settings
+	Depth int // how many moves ahead it looks at most, 0 is as far as it can
+
+	memo  map[string]int
+	nodes int       // positions looked at for this move
+	stop  time.Time // when this move has to be done by
+	cut   bool      // the last pass stopped at its depth somewhere
+	book  *Solver   // plays out of an opening book, see UseBook
+}
+
+// NewSearch is a Search for games with rules
+func NewSearch(rules Settings, depth int) *Search {
+	return &Search{Rules: rules, Depth: depth, memo: map[string]int{}}
+}
+
+// Best is the move with the best score for whoever's turn it is. Moves
+// that score the same are picked between at random.
+func (s *Search) Best(state State) (Input, bool) {
+	moves := state.Moves()
+	if len(moves) == 0 {
+		return Input{}, false
+	}
+	if s.book != nil {
+		return s.fromBook(state)
+	}
+	rand.Shuffle(len(moves), func(i, j int) { moves[i], moves[j] = moves[j], moves[i] })
+	if len(s.memo) > 1000000 {
+		s.memo = map[string]int{} // big boards would never stop growing it
+	}
+
+	move := moves[0]
+	s.nodes, s.stop = 0, time.Now().Add(searchTime)
+	for depth := 1; s.Depth == 0 || depth <= s.Depth; depth++ {
+		s.cut = false
+		in, ok := s.pass(state, moves, depth)
+		if !ok {
+			break // ran out, the last whole pass has to do
+		}
+		move = in
+		if !s.cut {
+			break // every line got to the end of the game
+		}
+	}
+	return move, true
+}
+
+// UseBook makes the search play perfectly out of b instead of looking
+// ahead, it has to be a book for the search's rules
+func (s *Search) UseBook(b *Book) error {
+	solver, err := NewSolver(s.Rules)
+	if err != nil {
+		return err
+	}
+	if err := solver.UseBook(b); err != nil {
+		return err
+	}
+	s.book = solver
+	return nil
+}
+
+// fromBook is one of the best moves by the book, picked at random
+func (s *Search) fromBook(state State) (Input, bool) {
+	var best []Input
+	var value Value
+	for _, mv := range s.book.Analyze(state) {
+		switch {
+		case len(best) == 0 || mv.Value.Better(value):
+			best, value = []Input{mv.Move}, mv.Value
+		case !value.Better(mv.Value):
+			best = append(best, mv.Move)
+		}
+	}
+	if len(best) == 0 {
+		return Input{}, false
+	}
+	return best[rand.Intn(len(best))], true
+}
+
+// pass scores every move depth moves ahead, false if it ran out of nodes
+// or time before it was done
+func (s *Search) pass(state State, moves []Input, depth int) (Input, bool) {
+	me := state.ToMove()
+	best, found := 0, false
+	var move Input
+	for _, in := range moves {
+		next, ok := After(s.Rules, state, in)
+		if !ok {
+			continue
+		}
+		score, ok := s.score(next, me, depth-1)
+		if !ok {
+			return move, false
+		}
+		if score = closer(score); !found || score > best {
+			best, move, found = score, in, true
+		}
+	}
+	return move, found
+}
+
+// score is how good state is for me, looking depth moves further. Nobody
+// winning by then is 0. False means it ran out of nodes or time.
+func (s *Search) score(state State, me, depth int) (int, bool) {
+	switch outcome := state.Outcome(); {
+	case outcome == fmt.Sprintf("Player %d", me):
+		return searchWin, true
+	case outcome == "CAT":
+		return 0, true
+	case outcome != "":
+		return -searchWin, true
+	case depth <= 0:
+		s.cut = true
+		return 0, true
+	}
+
+	data, err := state.Save()
+	if err != nil {
+		return 0, true
+	}
+	// a score that got to the end of every line is the same at any depth
+	exact := fmt.Sprintf("%d %s", me, data)
+	key := fmt.Sprintf("%d %d %s", me, depth, data)
+	if score, ok := s.memo[exact]; ok {
+		return score, true
+	}
+	if score, ok := s.memo[key]; ok {
+		s.cut = true
+		return score, true
+	}
+	if s.nodes++; s.nodes > searchNodes || (s.nodes%100 == 0 && time.Now().After(s.stop)) {
+		return 0, false
+	}
+	cut := s.cut
+	s.cut = false
+
+	mine := state.ToMove() == me
+	best, found := 0, false
+	for _, in := range state.Moves() {
+		next, ok := After(s.Rules, state, in)
+		if !ok {
+			continue
+		}
+		score, ok := s.score(next, me, depth-1)
+		if !ok {
+			return 0, false
+		}
+		if score = closer(score); !found || (mine && score > best) || (!mine && score < best) {
+			best, found = score, true
+		}
+	}
+	if s.cut {
+		s.memo[key] = best
+	} else {
+		s.memo[exact] = best
+	}
+	s.cut = s.cut || cut
+	return best, true
+}
+
+// closer is a score one move further from the end
+func closer(score int) int {
+	switch {
+	case score > 0:
+		return score - 1
+	case score < 0:
+		return score + 1
+	}
+	return 0
+}
+
+// RandomMove is any move for whoever's turn it is
+func RandomMove(state State) (Input, bool) {
+	moves := state.Moves()
+	if len(moves) == 0 {
+		return Input{}, false
+	}
+	return moves[rand.Intn(len(moves))], true
+}
+
+// After is the game once in is played, on a copy so state doesn't change
+func After(rules Settings, state State, in Input) (State, bool) {
+	if g, ok := state.(*PlainGame); ok {
+		// the searches copy a lot, this one doesn't need to go through JSON
+		next := *g
+		next.Board = g.Board.Clone()
+		return &next, next.Apply(in) == nil
+	}
+	data, err := state.Save()
+	if err != nil {
+		return nil, false
+	}
+	next, err := rules.Load(data)
+	if err != nil {
+		return nil, false
+	}
+	return next, next.Apply(in) == nil
+}
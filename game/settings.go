@@ -0,0 +1,143 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Settings describe the board a room is played on: Rows x Cols cells and K
+// in a row to win. Plain tic tac toe is 3, 3, 3. Layers makes it a 3D board
+// with that many Rows x Cols layers, 0 is the same as 1 (notakto uses them
+// as its separate boards). Variant picks different rules, "" is the normal
+// game. With Gravity you only pick a column and the piece falls to the
+// lowest empty cell in it, like connect four. Players is how many take
+// turns, 0 is the same as 2.
+type Settings struct {
+	Layers  int `json:",omitempty"`
+	Rows    int
+	Cols    int
+	K       int
+	Variant string `json:",omitempty"`
+	Gravity bool   `json:",omitempty"`
+	Players int    `json:",omitempty"`
+}
+
+// variants
+const (
+	VariantUltimate = "ultimate" // nine small boards, see UltimateState
+	VariantMisere   = "misere"   // k in a row loses
+	VariantNotakto  = "notakto"  // both play X on Layers 3x3 boards, killing the last board loses
+	VariantWild     = "wild"     // both players pick X or O every move
+	VariantOrder    = "order"    // order and chaos, player 1 wants k in a row of either, player 2 a full board
+	VariantQuantum  = "quantum"  // spooky marks in two cells at once, see QuantumState
+	VariantNumbers  = "numbers"  // odd against even numbers, a line adding up to 15 wins
+)
+
+// Classic is the normal 3x3 game
+var Classic = Settings{Rows: 3, Cols: 3, K: 3}
+
+// Presets are the boards the client lets you pick from
+var Presets = []Settings{
+	Classic,
+	{Rows: 4, Cols: 4, K: 4},
+	{Rows: 5, Cols: 5, K: 4},
+	{Rows: 15, Cols: 15, K: 5}, // gomoku
+	Ultimate,
+	Qubic,
+	{Rows: 3, Cols: 3, K: 3, Variant: VariantMisere},
+	{Layers: 3, Rows: 3, Cols: 3, K: 3, Variant: VariantNotakto},
+	{Rows: 3, Cols: 3, K: 3, Variant: VariantWild},
+	{Rows: 6, Cols: 6, K: 5, Variant: VariantOrder},
+	{Rows: 3, Cols: 3, K: 3, Variant: VariantQuantum},
+	{Rows: 3, Cols: 3, K: 3, Variant: VariantNumbers},
+	{Rows: 6, Cols: 7, K: 4, Gravity: true}, // connect four
+	{Rows: 6, Cols: 6, K: 4, Players: 3},
+}
+
+// Ultimate is ultimate tic tac toe, its nine small boards make a 9x9 board
+var Ultimate = Settings{Rows: 9, Cols: 9, K: 3, Variant: VariantUltimate}
+
+// Qubic is 3D tic tac toe, 4 in a row on a 4x4x4 cube
+var Qubic = Settings{Layers: 4, Rows: 4, Cols: 4, K: 4}
+
+// biggest 3D board, the layers are drawn side by side so they can't be big
+const MaxCubeSize = 4
+
+// most boards a game of notakto can have, for the same reason
+const MaxNotaktoBoards = 4
+
+// biggest board we allow, anything larger doesn't fit in the window
+const MaxBoardSize = 19
+
+// most players a room can have
+const MaxPlayers = 4
+
+// Seats is how many players the game is for
+func (s Settings) Seats() int {
+	if s.Players == 0 {
+		return 2
+	}
+	return s.Players
+}
+
+// Turn is the player whose move it is on turn, they go round in order
+// starting from player 1
+func (s Settings) Turn(turn int) int {
+	return (turn-1)%s.Seats() + 1
+}
+
+// Validate checks the settings make a playable game. Most of that is up to
+// the variant.
+func (s Settings) Validate() error {
+	if s.Layers < 0 {
+		return errors.New("a board can't have less than one layer")
+	}
+	if s.Players < 0 || s.Players == 1 || s.Players > MaxPlayers {
+		return fmt.Errorf("games are for 2 to %d players", MaxPlayers)
+	}
+	v, err := Lookup(s.Variant)
+	if err != nil {
+		return err
+	}
+	return v.Validate(s)
+}
+
+// Name is a short description like "15x15, 5 in a row"
+func (s Settings) Name() string {
+	switch s.Variant {
+	case VariantUltimate:
+		return "ultimate, 9 boards"
+	case VariantMisere:
+		return fmt.Sprintf("misere %dx%d, %d in a row loses", s.Rows, s.Cols, s.K)
+	case VariantNotakto:
+		return fmt.Sprintf("notakto, %d boards", max(s.Layers, 1))
+	case VariantWild:
+		return fmt.Sprintf("wild %dx%d, %d in a row", s.Rows, s.Cols, s.K)
+	case VariantOrder:
+		return fmt.Sprintf("order and chaos %dx%d, %d in a row", s.Rows, s.Cols, s.K)
+	case VariantQuantum:
+		return "quantum 3x3"
+	case VariantNumbers:
+		return "numbers, make 15"
+	}
+	if s.Variant != "" {
+		// one from a file
+		return fmt.Sprintf("%s %dx%d", s.Variant, s.Rows, s.Cols)
+	}
+	if s.Layers > 1 {
+		return fmt.Sprintf("3D %dx%dx%d, %d in a row", s.Layers, s.Rows, s.Cols, s.K)
+	}
+	name := fmt.Sprintf("%dx%d, %d in a row", s.Rows, s.Cols, s.K)
+	if s.Gravity {
+		name = fmt.Sprintf("%dx%d gravity, %d in a row", s.Rows, s.Cols, s.K)
+	}
+	if s.Seats() > 2 {
+		name += fmt.Sprintf(", %d players", s.Seats())
+	}
+	return name
+}
+
+// NewBoard makes an empty board of the right size
+func (s Settings) NewBoard() Board {
+	return NewCube(max(s.Layers, 1), s.Rows, s.Cols)
+}
@@ -0,0 +1,155 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+// the most cells a board can have for the solver to go through every
+// game on it, 3x3 is all it's fast enough for
+const solveCells = 9
+
+// Value is how a game turns out with perfect play, for the player whose
+// turn it is
+type Value struct {
+	Result int // 1 they win, 0 a draw, -1 they lose
+	Moves  int // how many moves until it's over
+}
+
+func (v Value) String() string {
+	switch v.Result {
+	case 1:
+		return fmt.Sprintf("win in %d", v.Moves)
+	case -1:
+		return fmt.Sprintf("loss in %d", v.Moves)
+	}
+	return "draw"
+}
+
+// rank puts values in order for the player they're for: quick wins, slow
+// wins, draws, slow losses and quick losses
+func (v Value) rank() int {
+	switch v.Result {
+	case 1:
+		return 100 - v.Moves
+	case -1:
+		return v.Moves - 100
+	}
+	return 0
+}
+
+// Better is whether v is a better result than w for the same player
+func (v Value) Better(w Value) bool {
+	return v.rank() > w.rank()
+}
+
+// MoveValue is one move and how the game goes for whoever makes it
+type MoveValue struct {
+	Move  Input
+	Value Value
+}
+
+// Solver plays perfectly on boards small enough to go through every game.
+// Positions it has solved are kept, so after the first move it's quick.
+type Solver struct {
+	rules Settings
+	memo  map[string]Value
+	book  *Book // positions it doesn't have to work out, see UseBook
+}
+
+// Solvable is whether the solver can do games with rules: two players on
+// a board of at most 9 cells, in variants where that's all there is to it
+func Solvable(rules Settings) bool {
+	switch rules.Variant {
+	case VariantQuantum, VariantNumbers, VariantUltimate:
+		return false // far more going on than the cells
+	}
+	return rules.Seats() == 2 && len(rules.NewBoard().Cells) <= solveCells
+}
+
+// NewSolver is a Solver for games with rules
+func NewSolver(rules Settings) (*Solver, error) {
+	if !Solvable(rules) {
+		return nil, errors.New("the solver only does 3x3 boards for two")
+	}
+	return &Solver{rules: rules, memo: map[string]Value{}}, nil
+}
+
+// Solve is how state ends with perfect play from here
+func (s *Solver) Solve(state State) Value {
+	if state.Outcome() != "" {
+		return result(state, state.ToMove())
+	}
+	data, err := state.Save()
+	if err != nil {
+		return Value{}
+	}
+	key := string(data)
+	if v, ok := s.memo[key]; ok {
+		return v
+	}
+	if s.book != nil {
+		if v, ok := s.book.Lookup(state); ok {
+			return v
+		}
+	}
+	var best Value
+	found := false
+	for _, mv := range s.Analyze(state) {
+		if !found || mv.Value.Better(best) {
+			best, found = mv.Value, true
+		}
+	}
+	s.memo[key] = best
+	return best
+}
+
+// Analyze is every move the player whose turn it is can make and how each
+// one goes for them
+func (s *Solver) Analyze(state State) []MoveValue {
+	me := state.ToMove()
+	var list []MoveValue
+	for _, in := range state.Moves() {
+		next, ok := After(s.rules, state, in)
+		if !ok {
+			continue
+		}
+		var v Value
+		switch {
+		case next.Outcome() != "":
+			v = result(next, me)
+		case next.ToMove() == me:
+			v = s.Solve(next) // house rules can have two moves in a row
+		default:
+			v = s.Solve(next)
+			v.Result = -v.Result // that was the other player's value
+		}
+		v.Moves++
+		list = append(list, MoveValue{Move: in, Value: v})
+	}
+	return list
+}
+
+// result is how a finished game went for me
+func result(state State, me int) Value {
+	switch outcome := state.Outcome(); outcome {
+	case "CAT":
+		return Value{}
+	case fmt.Sprintf("Player %d", me):
+		return Value{Result: 1}
+	}
+	return Value{Result: -1}
+}
+
+// Best is the best move for whoever's turn it is, the quickest win there is
+// or the slowest loss
+func (s *Solver) Best(state State) (Input, bool) {
+	var best MoveValue
+	found := false
+	for _, mv := range s.Analyze(state) {
+		if !found || mv.Value.Better(best.Value) {
+			best, found = mv, true
+		}
+	}
+	return best.Move, found
+}
@@ -0,0 +1,125 @@
+package game
+
+import "testing"
+
+func TestSolvable(t *testing.T) {
+	tests := []struct {
+		rules Settings
+		want  bool
+	}{
+		{Classic, true},
+		{Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantMisere}, true},
+		{Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantWild}, true},
+		{Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantNotakto}, true},
+		{Settings{Rows: 3, Cols: 3, K: 3, Gravity: true}, true},
+		{Settings{Rows: 4, Cols: 4, K: 4}, false},
+		{Settings{Layers: 2, Rows: 3, Cols: 3, K: 3, Variant: VariantNotakto}, false},
+		{Settings{Rows: 3, Cols: 3, K: 3, Players: 3}, false},
+		{Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantQuantum}, false},
+		{Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantNumbers}, false},
+		{Ultimate, false},
+	}
+	for _, tt := range tests {
+		if got := Solvable(tt.rules); got != tt.want {
+			t.Errorf("Solvable(%s) = %v, want %v", tt.rules.Name(), got, tt.want)
+		}
+		if _, err := NewSolver(tt.rules); (err == nil) != tt.want {
+			t.Errorf("NewSolver(%s) = %v", tt.rules.Name(), err)
+		}
+	}
+}
+
+func TestSolve(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules Settings
+		moves []string
+		want  Value
+	}{
+		{"start", Classic, nil, Value{Result: 0, Moves: 9}},
+		{"corner, answered in the middle", Classic, []string{"a1", "b2"}, Value{Result: 0, Moves: 7}},
+		{"corner, answered on an edge", Classic, []string{"a1", "b1"}, Value{Result: 1, Moves: 5}},
+		{"middle, answered on an edge", Classic, []string{"b2", "b1"}, Value{Result: 1, Moves: 5}},
+		{"one move from a win", Classic, []string{"a1", "a2", "b1", "b2"}, Value{Result: 1, Moves: 1}},
+		{"two threats", Classic, []string{"a1", "b1", "c1", "b2", "c3"}, Value{Result: 1, Moves: 1}},
+		{"can't stop both", Classic, []string{"b2", "b1", "a1", "c3", "a3"}, Value{Result: -1, Moves: 2}},
+		{"game over", Classic, []string{"a1", "a2", "b1", "b2", "c1"}, Value{Result: -1}},
+		{"misere start", Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantMisere}, nil, Value{Result: 0, Moves: 9}},
+		{"wild start", Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantWild}, nil, Value{Result: 1, Moves: 7}},
+		{"notakto start", Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantNotakto}, nil, Value{Result: 1, Moves: 6}},
+		{"gravity start", Settings{Rows: 3, Cols: 3, K: 3, Gravity: true}, nil, Value{Result: 0, Moves: 9}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSolver(tt.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Solve(play(t, tt.rules, tt.moves...)); got != tt.want {
+				t.Errorf("Solve = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBest(t *testing.T) {
+	tests := []struct {
+		name  string
+		moves []string
+		want  []string // any of these
+	}{
+		{"take the win", []string{"a1", "a2", "b1", "b2"}, []string{"c1"}},
+		{"block", []string{"a1", "b2", "b1"}, []string{"c1"}},
+		{"the only draw after a corner", []string{"a1"}, []string{"b2"}},
+		{"quickest of two wins", []string{"a1", "b1", "c1", "a2", "b2", "c2"}, []string{"a3", "c3"}},
+	}
+	s, err := NewSolver(Classic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, ok := s.Best(play(t, Classic, tt.moves...))
+			if !ok {
+				t.Fatal("no move")
+			}
+			got := FormatMove(Classic, in)
+			for _, want := range tt.want {
+				if got == want {
+					return
+				}
+			}
+			t.Errorf("Best = %s, want one of %v", got, tt.want)
+		})
+	}
+}
+
+func TestValueOrder(t *testing.T) {
+	// best first
+	values := []Value{
+		{Result: 1, Moves: 1},
+		{Result: 1, Moves: 5},
+		{Result: 0, Moves: 1},
+		{Result: -1, Moves: 6},
+		{Result: -1, Moves: 2},
+	}
+	for i := range values {
+		for j := range values {
+			if got := values[i].Better(values[j]); got != (i < j) {
+				t.Errorf("%v better than %v = %v", values[i], values[j], got)
+			}
+		}
+	}
+	for _, tt := range []struct {
+		v    Value
+		want string
+	}{
+		{Value{Result: 1, Moves: 3}, "win in 3"},
+		{Value{Result: -1, Moves: 2}, "loss in 2"},
+		{Value{Moves: 9}, "draw"},
+	} {
+		if got := tt.v.String(); got != tt.want {
+			t.Errorf("%+v is %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Ultimate tic tac toe is played on nine small 3x3 boards laid out as a 9x9
+// Board. Where you play inside a small board picks the small board your
+// opponent has to play in next. Taking three small boards in a row wins.
+
+// UltimateState is what an ultimate game needs on top of its 9x9 board
+type UltimateState struct {
+	// who took each small board, numbered 0-8 left to right then top to
+	// bottom: 0 while it's open, the player who got 3 in a row, or -1 when it
+	// filled up without one
+	Boards [9]int
+	// small board the next move has to go in, -1 means any open one
+	Next int
+}
+
+func NewUltimateState() UltimateState {
+	return UltimateState{Next: -1}
+}
+
+// UltimateCell turns row, col inside small board small into the cell of the
+// 9x9 board
+func UltimateCell(small, row, col int) (int, int) {
+	return small/3*3 + row, small%3*3 + col
+}
+
+// Play puts player's mark at row, col of small board small if the rules
+// allow it
+func (u *UltimateState) Play(b Board, small, row, col, player int) error {
+	if small < 0 || small > 8 || row < 0 || row > 2 || col < 0 || col > 2 {
+		return errors.New("that's not on the board")
+	}
+	if u.Next != -1 && small != u.Next {
+		return errors.New("you have to play in the highlighted board")
+	}
+	if u.Boards[small] != 0 {
+		return errors.New("that board is already finished")
+	}
+	bigRow, bigCol := UltimateCell(small, row, col)
+	if b.At(bigRow, bigCol) != 0 {
+		return errors.New("that cell is taken")
+	}
+	b.Set(bigRow, bigCol, player)
+
+	// did that finish the small board?
+	u.Boards[small] = smallResult(b, small)
+
+	// the opponent gets sent to the board matching the cell we played, or
+	// anywhere if that one is finished
+	u.Next = row*3 + col
+	if u.Boards[u.Next] != 0 {
+		u.Next = -1
+	}
+	return nil
+}
+
+// Meta is the 3x3 board of who took which small board. Drawn small boards
+// are -1 so they block lines for both players.
+func (u UltimateState) Meta() Board {
+	meta := NewBoard(3, 3)
+	copy(meta.Cells, u.Boards[:])
+	return meta
+}
+
+// smallResult is who took small board small of b: 0 while it's open, the
+// player with 3 in a row or -1 when it's full without one
+func smallResult(b Board, small int) int {
+	part := NewBoard(3, 3)
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			part.Set(r, c, b.At(UltimateCell(small, r, c)))
+		}
+	}
+	if line, ok := part.FindLine(3); ok {
+		return line.Player
+	} else if part.Full() {
+		return -1
+	}
+	return 0
+}
+
+// ultimateOutcome works like the normal one but on the small boards
+func ultimateOutcome(b Board, s Settings) string {
+	var u UltimateState
+	for small := range u.Boards {
+		u.Boards[small] = smallResult(b, small)
+	}
+	return Outcome(u.Meta(), 3)
+}
+
+// ultimate is the Variant
+type ultimate struct{}
+
+func (ultimate) Validate(s Settings) error {
+	if s != Ultimate {
+		return errors.New("ultimate is always played on nine 3x3 boards")
+	}
+	return nil
+}
+
+func (ultimate) Start(s Settings) State {
+	return &UltimateGame{
+		PlainGame: PlainGame{Board: s.NewBoard(), Turn: 1, rules: s, outcome: ultimateOutcome},
+		Ultimate:  NewUltimateState(),
+	}
+}
+
+func (ultimate) Load(s Settings, data []byte) (State, error) {
+	g := &UltimateGame{PlainGame: PlainGame{rules: s, outcome: ultimateOutcome}}
+	err := json.Unmarshal(data, g)
+	return g, err
+}
+
+// UltimateGame is a game of ultimate, the board plus the small boards
+type UltimateGame struct {
+	PlainGame
+	Ultimate UltimateState
+}
+
+func (g *UltimateGame) Apply(in Input) error {
+	if err := g.Ultimate.Play(g.Board, in.Board, in.Row, in.Col, in.Player); err != nil {
+		return err
+	}
+	g.Turn++
+	return nil
+}
+
+func (g *UltimateGame) Moves() []Input {
+	player := g.ToMove()
+	var moves []Input
+	for small := 0; small < 9; small++ {
+		if g.Ultimate.Boards[small] != 0 || (g.Ultimate.Next != -1 && g.Ultimate.Next != small) {
+			continue
+		}
+		for row := 0; row < 3; row++ {
+			for col := 0; col < 3; col++ {
+				if g.Board.At(UltimateCell(small, row, col)) == 0 {
+					moves = append(moves, Input{Player: player, Board: small, Pos: Pos{Row: row, Col: col}})
+				}
+			}
+		}
+	}
+	return moves
+}
+
+func (g *UltimateGame) Save() ([]byte, error) {
+	return json.Marshal(g)
+}
@@ -0,0 +1,192 @@
+package game
+
+import "testing"
+
+func TestUltimateCell(t *testing.T) {
+	tests := []struct {
+		small, row, col int
+		bigRow, bigCol  int
+	}{
+		{0, 0, 0, 0, 0},
+		{4, 1, 1, 4, 4},
+		{2, 0, 2, 0, 8},
+		{6, 2, 0, 8, 0},
+		{8, 2, 2, 8, 8},
+		{5, 1, 0, 4, 6},
+	}
+	for _, tt := range tests {
+		r, c := UltimateCell(tt.small, tt.row, tt.col)
+		if r != tt.bigRow || c != tt.bigCol {
+			t.Errorf("UltimateCell(%d, %d, %d) = %d, %d, want %d, %d", tt.small, tt.row, tt.col, r, c, tt.bigRow, tt.bigCol)
+		}
+	}
+}
+
+// where you play picks the small board the other player has to play in
+func TestUltimateNext(t *testing.T) {
+	tests := []struct {
+		name  string
+		moves []string
+		next  int
+		legal int // how many moves the next player has
+	}{
+		{"start", nil, -1, 81},
+		{"top left corner", []string{"a1"}, 0, 8},
+		{"middle of the middle", []string{"e5"}, 4, 8},
+		{"sent back", []string{"a1", "b2"}, 4, 9},
+		{"bottom right", []string{"e5", "f6"}, 8, 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := play(t, Ultimate, tt.moves...).(*UltimateGame)
+			if g.Ultimate.Next != tt.next {
+				t.Errorf("Next = %d, want %d", g.Ultimate.Next, tt.next)
+			}
+			moves := g.Moves()
+			if len(moves) != tt.legal {
+				t.Errorf("%d moves, want %d", len(moves), tt.legal)
+			}
+			for _, in := range moves {
+				if tt.next != -1 && in.Board != tt.next {
+					t.Errorf("move in board %d, want %d", in.Board, tt.next)
+				}
+			}
+		})
+	}
+}
+
+func TestUltimatePlayErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		moves []string
+		bad   string
+	}{
+		{"wrong board", []string{"a1"}, "e5"},
+		{"taken", []string{"a1", "a1"}, "a1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := Ultimate.Start()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, text := range tt.moves {
+				err := move(state, Ultimate, text)
+				if i < len(tt.moves)-1 && err != nil {
+					t.Fatalf("%s: %v", text, err)
+				}
+			}
+			if err := move(state, Ultimate, tt.bad); err == nil {
+				t.Errorf("%s was allowed", tt.bad)
+			}
+		})
+	}
+
+	var u UltimateState
+	if err := u.Play(NewBoard(9, 9), 9, 0, 0, 1); err == nil {
+		t.Error("board 9 was allowed")
+	}
+}
+
+// a small board that's won or full can't be played in, and being sent to
+// one means playing anywhere
+func TestUltimateFinishedBoard(t *testing.T) {
+	tests := []struct {
+		name   string
+		cells  [8]int // small board 0 but its bottom right cell
+		player int    // who plays the bottom right cell
+		want   int
+	}{
+		{"x took it", [8]int{1, 2, 0, 2, 1, 0, 0, 0}, 1, 1},
+		{"o took it", [8]int{2, 1, 1, 1, 2, 0, 0, 0}, 2, 2},
+		{"drawn", [8]int{1, 2, 1, 1, 2, 2, 2, 1}, 1, -1},
+		{"still open", [8]int{1, 2, 0, 0, 0, 0, 0, 0}, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBoard(9, 9)
+			for i, v := range tt.cells {
+				b.Set(i/3, i%3, v)
+			}
+			u := NewUltimateState()
+			if err := u.Play(b, 0, 2, 2, tt.player); err != nil {
+				t.Fatal(err)
+			}
+			if u.Boards[0] != tt.want {
+				t.Fatalf("Boards[0] = %d, want %d", u.Boards[0], tt.want)
+			}
+			if tt.want == 0 {
+				return
+			}
+			// send the next player to board 0 from the middle one
+			u.Next = 4
+			if err := u.Play(b, 4, 0, 0, 3-tt.player); err != nil {
+				t.Fatal(err)
+			}
+			if u.Next != -1 {
+				t.Errorf("Next = %d, want -1 after being sent to a finished board", u.Next)
+			}
+			if err := u.Play(b, 0, 0, 2, tt.player); err == nil {
+				t.Error("a move in a finished board was allowed")
+			}
+		})
+	}
+}
+
+// the game is won by three small boards in a row, and drawn small boards
+// block lines for both players
+func TestUltimateOutcome(t *testing.T) {
+	tests := []struct {
+		name   string
+		boards map[int]int // small board to who takes it, -1 drawn
+		want   string
+	}{
+		{"nothing yet", nil, ""},
+		{"top row", map[int]int{0: 1, 1: 1, 2: 1}, "Player 1"},
+		{"diagonal", map[int]int{2: 2, 4: 2, 6: 2}, "Player 2"},
+		{"drawn board blocks", map[int]int{0: 1, 1: 1, 2: -1}, ""},
+		{"two isn't enough", map[int]int{0: 1, 4: 1, 7: 2}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBoard(9, 9)
+			for small, who := range tt.boards {
+				fill(b, small, who)
+			}
+			if got := ultimateOutcome(b, Ultimate); got != tt.want {
+				t.Errorf("ultimateOutcome = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// fill marks small board small as taken by who, or drawn for -1
+func fill(b Board, small, who int) {
+	cells := [9]int{who, who, who}
+	if who == -1 {
+		cells = [9]int{1, 2, 1, 1, 2, 2, 2, 1, 1}
+	}
+	for i, v := range cells {
+		row, col := UltimateCell(small, i/3, i%3)
+		b.Set(row, col, v)
+	}
+}
+
+func TestUltimateSaveLoad(t *testing.T) {
+	state := play(t, Ultimate, "e5", "f6", "h8")
+	data, err := state.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Ultimate.Load(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := loaded.(*UltimateGame)
+	if g.Ultimate != state.(*UltimateGame).Ultimate || g.CurrentTurn() != 4 || g.Grid().At(7, 7) != 1 {
+		t.Errorf("loaded %+v on turn %d", g.Ultimate, g.CurrentTurn())
+	}
+	if err := move(loaded, Ultimate, "a1"); err == nil {
+		t.Error("the loaded game let O play outside the board it was sent to")
+	}
+}
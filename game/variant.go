@@ -0,0 +1,85 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Variant is one set of rules. Every variant is registered under the name
+// that goes in Settings.Variant, the normal game is "". The server looks the
+// rules of a room up here and the client uses the name to pick how to draw
+// the game, so a new variant only needs registering and a renderer.
+type Variant interface {
+	// Validate checks the settings can be played with these rules
+	Validate(s Settings) error
+	// Start is a new game
+	Start(s Settings) State
+	// Load reads a game back from what State.Save wrote
+	Load(s Settings, data []byte) (State, error)
+}
+
+// State is a game being played
+type State interface {
+	// Grid is the board with the marks that are down
+	Grid() Board
+	// CurrentTurn counts turns from 1
+	CurrentTurn() int
+	// ToMove is the player whose turn it is
+	ToMove() int
+	// Moves lists every move the player whose turn it is can make
+	Moves() []Input
+	// Apply plays in for in.Player, it has to be their turn
+	Apply(in Input) error
+	// Outcome is "Player N" for the winner, "CAT" for a draw and "" while
+	// the game is still going
+	Outcome() string
+	// Save is the whole state as JSON, it's what clients get in Update.State
+	Save() ([]byte, error)
+}
+
+var variants = map[string]Variant{}
+
+// Register makes a variant available under name
+func Register(name string, v Variant) {
+	if _, dup := variants[name]; dup {
+		panic("variant registered twice: " + name)
+	}
+	variants[name] = v
+}
+
+// Lookup finds the variant registered under name
+func Lookup(name string) (Variant, error) {
+	v, ok := variants[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown variant %q", name)
+	}
+	return v, nil
+}
+
+// VariantNames lists every registered variant
+func VariantNames() []string {
+	var names []string
+	for name := range variants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Start looks up the variant of the settings and starts a game with it
+func (s Settings) Start() (State, error) {
+	v, err := Lookup(s.Variant)
+	if err != nil {
+		return nil, err
+	}
+	return v.Start(s), nil
+}
+
+// Load reads a game saved with State.Save
+func (s Settings) Load(data []byte) (State, error) {
+	v, err := Lookup(s.Variant)
+	if err != nil {
+		return nil, err
+	}
+	return v.Load(s, data)
+}
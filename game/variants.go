@@ -0,0 +1,179 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+func init() {
+	Register("", plain{validate: validateNormal, outcome: normalOutcome})
+	Register(VariantMisere, plain{validate: validateFlat, outcome: misereOutcome})
+	Register(VariantNotakto, plain{validate: validateNotakto, outcome: notaktoOutcome})
+	Register(VariantWild, plain{validate: validateFlat, outcome: wildOutcome})
+	Register(VariantOrder, plain{validate: validateFlat, outcome: orderOutcome})
+	Register(VariantNumbers, plain{validate: validate3x3, outcome: numericalOutcome})
+	Register(VariantUltimate, ultimate{})
+	Register(VariantQuantum, quantum{})
+}
+
+// plain is every variant that only needs the board: the normal game and the
+// ones that change who wins or what gets put down
+type plain struct {
+	validate func(s Settings) error
+	outcome  func(b Board, s Settings) string
+}
+
+func (v plain) Validate(s Settings) error {
+	return v.validate(s)
+}
+
+func (v plain) Start(s Settings) State {
+	return &PlainGame{Board: s.NewBoard(), Turn: 1, rules: s, outcome: v.outcome}
+}
+
+func (v plain) Load(s Settings, data []byte) (State, error) {
+	g := &PlainGame{rules: s, outcome: v.outcome}
+	err := json.Unmarshal(data, g)
+	return g, err
+}
+
+// PlainGame is a game that's nothing but its board
+type PlainGame struct {
+	Board Board
+	Turn  int
+
+	rules   Settings
+	outcome func(b Board, s Settings) string
+}
+
+func (g *PlainGame) Grid() Board      { return g.Board }
+func (g *PlainGame) CurrentTurn() int { return g.Turn }
+func (g *PlainGame) ToMove() int      { return g.rules.Turn(g.Turn) }
+
+func (g *PlainGame) Outcome() string {
+	return g.outcome(g.Board, g.rules)
+}
+
+func (g *PlainGame) Save() ([]byte, error) {
+	return json.Marshal(g)
+}
+
+func (g *PlainGame) Apply(in Input) error {
+	in, symbol, err := g.check(in)
+	if err != nil {
+		return err
+	}
+	g.Board.Put(in.Pos, symbol)
+	g.Turn++
+	return nil
+}
+
+// check works out where in really goes and what gets put there
+func (g *PlainGame) check(in Input) (Input, int, error) {
+	if g.rules.Gravity && in.Col >= 0 && in.Col < g.Board.Cols {
+		row, ok := g.Board.Drop(in.Col)
+		if !ok {
+			return in, 0, errors.New("that column is full")
+		}
+		in.Row, in.Layer = row, 0
+	}
+	if !g.Board.Contains(in.Pos) {
+		return in, 0, errors.New("that's not on the board")
+	}
+	if g.Board.Get(in.Pos) != 0 {
+		return in, 0, errors.New("that cell is taken")
+	}
+	if err := g.rules.Check(g.Board, in.Pos); err != nil {
+		return in, 0, err
+	}
+	symbol, err := g.rules.Symbol(g.Board, in.Player, in.Symbol)
+	return in, symbol, err
+}
+
+func (g *PlainGame) Moves() []Input {
+	player := g.ToMove()
+	symbols := []int{0}
+	switch {
+	case g.rules.Variant == VariantNumbers:
+		symbols = Numbers(g.Board, player)
+	case g.rules.PickSymbol():
+		symbols = []int{X, O}
+	}
+
+	var moves []Input
+	for i, v := range g.Board.Cells {
+		if v != 0 {
+			continue
+		}
+		p := g.Board.pos(i)
+		if g.rules.Gravity {
+			if row, _ := g.Board.Drop(p.Col); row != p.Row {
+				continue
+			}
+		}
+		for _, symbol := range symbols {
+			in := Input{Player: player, Symbol: symbol, Pos: p}
+			if _, _, err := g.check(in); err == nil {
+				moves = append(moves, in)
+			}
+		}
+	}
+	return moves
+}
+
+// validateNormal is for the normal game, which can also be 3D or have more
+// than two players
+func validateNormal(s Settings) error {
+	if s.Layers > 1 {
+		// lines through the layers only work on a cube
+		if s.Layers != s.Rows || s.Rows != s.Cols || s.Layers < 3 || s.Layers > MaxCubeSize || s.K != s.Layers {
+			return fmt.Errorf("3D boards have to be cubes between 3x3x3 and %dx%dx%d with K the same as the side", MaxCubeSize, MaxCubeSize, MaxCubeSize)
+		}
+		if s.Gravity || s.Seats() > 2 {
+			return errors.New("3D games are for two players without gravity")
+		}
+		return nil
+	}
+	if s.Rows < 3 || s.Cols < 3 || s.Rows > MaxBoardSize || s.Cols > MaxBoardSize {
+		return fmt.Errorf("boards have to be between 3x3 and %dx%d", MaxBoardSize, MaxBoardSize)
+	}
+	if s.K < 3 || (s.K > s.Rows && s.K > s.Cols) {
+		return fmt.Errorf("can't fit %d in a row on a %dx%d board", s.K, s.Rows, s.Cols)
+	}
+	return nil
+}
+
+// validateFlat is for variants that work on any flat board, but only with
+// two players
+func validateFlat(s Settings) error {
+	if s.Layers > 1 {
+		return fmt.Errorf("%s can't be played in 3D", s.Variant)
+	}
+	if err := twoPlayers(s); err != nil {
+		return err
+	}
+	return validateNormal(s)
+}
+
+func validateNotakto(s Settings) error {
+	if s.Rows != 3 || s.Cols != 3 || s.K != 3 || s.Layers > MaxNotaktoBoards || s.Gravity {
+		return fmt.Errorf("notakto is played on 1 to %d 3x3 boards", MaxNotaktoBoards)
+	}
+	return twoPlayers(s)
+}
+
+// validate3x3 is for variants that only work on the normal board
+func validate3x3(s Settings) error {
+	if s.Layers > 1 || s.Rows != 3 || s.Cols != 3 || s.K != 3 || s.Gravity {
+		return fmt.Errorf("%s is only played on 3x3", s.Variant)
+	}
+	return twoPlayers(s)
+}
+
+func twoPlayers(s Settings) error {
+	if s.Seats() != 2 {
+		return fmt.Errorf("%s is for two players", s.Variant)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package game
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	for _, s := range Presets {
+		if err := s.Validate(); err != nil {
+			t.Errorf("preset %s: %v", s.Name(), err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		s    Settings
+		ok   bool
+	}{
+		{"long and thin", Settings{Rows: 3, Cols: 19, K: 3}, true},
+		{"one notakto board", Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantNotakto}, true},
+		{"four players", Settings{Rows: 8, Cols: 8, K: 4, Players: 4}, true},
+		{"misere gravity", Settings{Rows: 6, Cols: 7, K: 4, Variant: VariantMisere, Gravity: true}, true},
+		{"too small", Settings{Rows: 2, Cols: 2, K: 2}, false},
+		{"too big", Settings{Rows: 20, Cols: 20, K: 5}, false},
+		{"k doesn't fit", Settings{Rows: 3, Cols: 3, K: 4}, false},
+		{"k too small", Settings{Rows: 5, Cols: 5, K: 2}, false},
+		{"not a cube", Settings{Layers: 3, Rows: 4, Cols: 4, K: 4}, false},
+		{"cube too big", Settings{Layers: 5, Rows: 5, Cols: 5, K: 5}, false},
+		{"3D gravity", Settings{Layers: 3, Rows: 3, Cols: 3, K: 3, Gravity: true}, false},
+		{"3D for three", Settings{Layers: 3, Rows: 3, Cols: 3, K: 3, Players: 3}, false},
+		{"negative layers", Settings{Layers: -1, Rows: 3, Cols: 3, K: 3}, false},
+		{"one player", Settings{Rows: 3, Cols: 3, K: 3, Players: 1}, false},
+		{"five players", Settings{Rows: 9, Cols: 9, K: 4, Players: 5}, false},
+		{"unknown variant", Settings{Rows: 3, Cols: 3, K: 3, Variant: "chess"}, false},
+		{"misere in 3D", Settings{Layers: 3, Rows: 3, Cols: 3, K: 3, Variant: VariantMisere}, false},
+		{"wild for three", Settings{Rows: 5, Cols: 5, K: 4, Variant: VariantWild, Players: 3}, false},
+		{"notakto 4x4", Settings{Rows: 4, Cols: 4, K: 3, Variant: VariantNotakto}, false},
+		{"too many notakto boards", Settings{Layers: 5, Rows: 3, Cols: 3, K: 3, Variant: VariantNotakto}, false},
+		{"small ultimate", Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantUltimate}, false},
+		{"quantum 4x4", Settings{Rows: 4, Cols: 4, K: 4, Variant: VariantQuantum}, false},
+		{"numbers for three", Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantNumbers, Players: 3}, false},
+	}
+	for _, tt := range tests {
+		err := tt.s.Validate()
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: Validate = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	var (
+		misere  = Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantMisere}
+		notakto = Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantNotakto}
+		two     = Settings{Layers: 2, Rows: 3, Cols: 3, K: 3, Variant: VariantNotakto}
+		wild    = Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantWild}
+		order   = Settings{Rows: 6, Cols: 6, K: 5, Variant: VariantOrder}
+		numbers = Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantNumbers}
+		gravity = Settings{Rows: 6, Cols: 7, K: 4, Gravity: true}
+		three   = Settings{Rows: 6, Cols: 6, K: 4, Players: 3}
+	)
+	tests := []struct {
+		name  string
+		rules Settings
+		moves []string
+		want  string
+	}{
+		{"going", Classic, []string{"a1", "b2"}, ""},
+		{"x wins", Classic, []string{"a1", "a2", "b1", "b2", "c1"}, "Player 1"},
+		{"o wins", Classic, []string{"a1", "b1", "a2", "b2", "c3", "b3"}, "Player 2"},
+		{"draw", Classic, []string{"a1", "b1", "c1", "b2", "a2", "c2", "b3", "a3", "c3"}, "CAT"},
+		{"misere line loses", misere, []string{"a1", "a2", "b1", "b2", "c1"}, "Player 2"},
+		{"notakto last board killed", notakto, []string{"a1", "b1", "c2", "c1"}, "Player 1"},
+		{"notakto one of two", two, []string{"1:a1", "1:b1", "1:c1"}, ""},
+		{"notakto both", two, []string{"1:a1", "1:b1", "1:c1", "2:a1", "2:b2", "2:a2", "2:c3"}, "Player 2"},
+		{"wild own line", wild, []string{"a1=o", "b2=x", "b1=o", "a3=x", "c1=o"}, "Player 1"},
+		{"wild finishing theirs", wild, []string{"a1=x", "b2=o", "b1=x", "c1=x"}, "Player 2"},
+		{"order", order, []string{"a1=x", "a6=o", "b1=x", "b6=o", "c1=x", "c6=o", "d1=x", "d6=o", "e1=x"}, "Player 1"},
+		{"chaos makes order's line", order, []string{"a1=x", "a6=o", "b1=x", "b6=o", "c1=x", "c6=o", "d1=x", "e1=x"}, "Player 1"},
+		{"numbers make 15", numbers, []string{"a1=9", "b1=2", "a3=1", "c1=4"}, "Player 2"},
+		{"numbers not full", numbers, []string{"a1=9", "b1=6"}, ""},
+		{"connect four", gravity, []string{"d", "e", "d", "e", "d", "e", "d"}, "Player 1"},
+		{"three players", three, []string{"a1", "a2", "a3", "b1", "b2", "b3", "c1", "c2", "c3", "d1"}, "Player 1"},
+		{"third player wins", three, []string{"a1", "b1", "a3", "a2", "b2", "b3", "c1", "c2", "c3", "f6", "f5", "d3"}, "Player 3"},
+		{"qubic down the layers", Qubic, []string{"1:a1", "1:b1", "2:a1", "2:b1", "3:a1", "3:b1", "4:a1"}, "Player 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := play(t, tt.rules, tt.moves...)
+			if got := state.Outcome(); got != tt.want {
+				t.Errorf("Outcome = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIllegalMoves(t *testing.T) {
+	var (
+		two     = Settings{Layers: 2, Rows: 3, Cols: 3, K: 3, Variant: VariantNotakto}
+		wild    = Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantWild}
+		numbers = Settings{Rows: 3, Cols: 3, K: 3, Variant: VariantNumbers}
+		gravity = Settings{Rows: 6, Cols: 7, K: 4, Gravity: true}
+	)
+	tests := []struct {
+		name  string
+		rules Settings
+		moves []string
+		bad   string
+	}{
+		{"taken", Classic, []string{"b2"}, "b2"},
+		{"off the board", Classic, nil, "d1"},
+		{"dead notakto board", two, []string{"1:a1", "1:b1", "1:c1"}, "1:a2"},
+		{"wild needs a symbol", wild, nil, "a1"},
+		{"even number for player 1", numbers, nil, "a1=2"},
+		{"number used twice", numbers, []string{"a1=9", "b1=2"}, "c1=9"},
+		{"full column", gravity, []string{"a", "a", "a", "a", "a", "a"}, "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := play(t, tt.rules, tt.moves...)
+			if err := move(state, tt.rules, tt.bad); err == nil {
+				t.Errorf("%s was allowed", tt.bad)
+			}
+		})
+	}
+}
+
+// every move a preset lists can be played, and a game saved part way
+// through loads back the same
+func TestPresets(t *testing.T) {
+	for _, rules := range Presets {
+		t.Run(rules.Name(), func(t *testing.T) {
+			state, err := rules.Start()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 4; i++ {
+				moves := state.Moves()
+				if len(moves) == 0 {
+					t.Fatalf("no moves on turn %d", state.CurrentTurn())
+				}
+				for _, in := range moves {
+					if _, ok := After(rules, state, in); !ok {
+						t.Fatalf("%s isn't allowed", FormatMove(rules, in))
+					}
+				}
+				if err := state.Apply(moves[len(moves)/2]); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			data, err := state.Save()
+			if err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := rules.Load(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			again, err := loaded.Save()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, again) {
+				t.Errorf("saved %s, loaded and saved again %s", data, again)
+			}
+			if loaded.ToMove() != state.ToMove() || len(loaded.Moves()) != len(state.Moves()) || loaded.Outcome() != state.Outcome() {
+				t.Error("the loaded game isn't the same game")
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	names := map[string]bool{}
+	for _, name := range VariantNames() {
+		names[name] = true
+	}
+	for _, name := range []string{"", VariantUltimate, VariantMisere, VariantNotakto, VariantWild, VariantOrder, VariantQuantum, VariantNumbers} {
+		if !names[name] {
+			t.Errorf("%q isn't registered", name)
+		}
+	}
+	if _, err := Lookup("chess"); err == nil {
+		t.Error("found a variant called chess")
+	}
+	if _, err := (Settings{Rows: 3, Cols: 3, K: 3, Variant: "chess"}).Start(); err == nil {
+		t.Error("started a game of chess")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering misere twice didn't panic")
+		}
+	}()
+	Register(VariantMisere, plain{validate: validateFlat, outcome: misereOutcome})
+}
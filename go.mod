@@ -5,6 +5,7 @@ go 1.25.1
 require (
 	github.com/hajimehoshi/ebiten/v2 v2.9.1
 	golang.org/x/image v0.32.0
+	golang.org/x/sys v0.36.0
 )
 
 require (
@@ -15,6 +16,5 @@ require (
 	github.com/jezek/xgb v1.1.1 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
 	golang.org/x/sync v0.17.0 // indirect
-	golang.org/x/sys v0.36.0 // indirect
 	golang.org/x/text v0.30.0 // indirect
 )
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"time"
+
+	"tictactoe/game"
+)
+
+// how fast a dropped piece speeds up, in pixels per second per second
+const dropAcceleration = 5000
+
+// startDrop finds the piece that's new in next and starts it falling. Only
+// gravity games animate.
+func (g *Game) startDrop(old, next game.Board) {
+	if !g.rules.Gravity || len(old.Cells) != len(next.Cells) {
+		return
+	}
+	for i := range next.Cells {
+		if old.Cells[i] == 0 && next.Cells[i] != 0 {
+			g.dropping = &game.Pos{Row: i / next.Cols, Col: i % next.Cols}
+			g.dropStart = time.Now()
+			return
+		}
+	}
+}
+
+// dropY is where to draw the piece at row, col. The falling one starts
+// above the board and falls down to y, everything else is just at y.
+func (g *Game) dropY(row, col int, y float64) float64 {
+	if g.dropping == nil || g.dropping.Row != row || g.dropping.Col != col {
+		return y
+	}
+	t := time.Since(g.dropStart).Seconds()
+	fall := float64(g.offset-g.cellSize) + dropAcceleration*t*t/2
+	if fall >= y {
+		g.dropping = nil
+		return y
+	}
+	return fall
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+
+	"tictactoe/game"
+)
+
+// the opening book from tictactoe solve, next to X.png
+const bookFile = "book.json"
+
+// the Hint and Values buttons, in the strip right of the board
+const (
+	hintBtnX   = 510
+	hintBtnY   = 60
+	valuesBtnY = 110
+	hintBtnW   = 80
+	hintBtnH   = 40
+)
+
+// hintsOn is whether the solver can help with the game on the screen. The
+// server turns it off for rated games, and puzzles do for themselves.
+func (g *Game) hintsOn() bool {
+	return g.current != nil && g.winner == "" && !g.noHints && game.Solvable(g.rules)
+}
+
+// updateHints handles H (or the Hint button), which shows the best move,
+// and V (or Values), which shows how every move turns out. It returns true
+// when it used the click.
+func (g *Game) updateHints(x, y int) bool {
+	if g.winner != "" {
+		return false // the game's over, the keys are for reviewing it
+	}
+	g.h_hint = inside(x, y, hintBtnX, hintBtnY, hintBtnW, hintBtnH)
+	g.h_values = inside(x, y, hintBtnX, valuesBtnY, hintBtnW, hintBtnH)
+	click := inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft)
+
+	hint := inpututil.IsKeyJustPressed(ebiten.KeyH) || (click && g.h_hint)
+	values := inpututil.IsKeyJustPressed(ebiten.KeyV) || (click && g.h_values)
+	if !hint && !values {
+		return false
+	}
+	switch {
+	case g.noHints:
+		g.addChat(game.Message{Text: "No hints in rated games or puzzles"})
+	case !g.hintsOn():
+		g.addChat(game.Message{Text: "No hints for this board"})
+	case values:
+		g.showValues = !g.showValues
+	case g.player != g.toMove:
+		g.addChat(game.Message{Text: "Hints are for your own turn"})
+	default:
+		if in, ok := g.solver().Best(g.current); ok {
+			g.hint = &in
+		}
+	}
+	return click && (g.h_hint || g.h_values)
+}
+
+// solver is the solver for the board we're on, it remembers what it's
+// worked out so there's one for each board
+func (g *Game) solver() *game.Solver {
+	if s, ok := g.solvers[g.rules]; ok {
+		return s
+	}
+	s, err := game.NewSolver(g.rules)
+	if err != nil {
+		return nil // hintsOn checks first
+	}
+	if book := g.openingBook(g.rules); book != nil {
+		s.UseBook(book)
+	}
+	if g.solvers == nil {
+		g.solvers = map[game.Settings]*game.Solver{}
+	}
+	g.solvers[g.rules] = s
+	return s
+}
+
+// openingBook is the book for rules, nil if book.json is for another board
+// or isn't there. It's only read the first time.
+func (g *Game) openingBook(rules game.Settings) *game.Book {
+	if !g.bookTried {
+		g.bookTried = true
+		book, err := game.LoadBook(bookFile)
+		if err != nil && !os.IsNotExist(err) {
+			fmt.Println("error loading the opening book:", err)
+		}
+		g.book = book
+	}
+	if g.book == nil || g.book.Rules != rules {
+		return nil
+	}
+	return g.book
+}
+
+// drawHints draws the buttons, and over the board the hint and what every
+// move is worth for whoever's turn it is
+func (g *Game) drawHints(screen *ebiten.Image) {
+	if !g.hintsOn() {
+		return
+	}
+	ebitenutil.DrawRect(screen, hintBtnX, hintBtnY, hintBtnW, hintBtnH, buttonColor(g.h_hint))
+	text.Draw(screen, "Hint", g.tinyFont, hintBtnX+22, hintBtnY+26, color.White)
+	ebitenutil.DrawRect(screen, hintBtnX, valuesBtnY, hintBtnW, hintBtnH, buttonColor(g.h_values))
+	text.Draw(screen, "Values", g.tinyFont, hintBtnX+14, valuesBtnY+26, color.White)
+
+	if g.showValues {
+		if g.values == nil || g.valuesOf != g.current {
+			g.values, g.valuesOf = g.solver().Analyze(g.current), g.current
+		}
+		// the best of the moves in each cell, wild has an X and an O there
+		best := map[game.Pos]game.Value{}
+		for _, mv := range g.values {
+			if v, ok := best[mv.Move.Pos]; !ok || mv.Value.Better(v) {
+				best[mv.Move.Pos] = mv.Value
+			}
+		}
+		for pos, v := range best {
+			x := g.offset + pos.Col*g.cellSize + 6
+			y := g.offset + pos.Row*g.cellSize + 20
+			text.Draw(screen, v.String(), g.tinyFont, x, y, valueColor(v))
+		}
+		text.Draw(screen, "for "+g.name(g.toMove), g.tinyFont, hintBtnX, valuesBtnY+hintBtnH+20, color.RGBA{160, 160, 160, 255})
+	}
+
+	if g.hint != nil {
+		gold := color.RGBA{255, 215, 0, 255}
+		g.drawFrame(screen, g.hint.Pos, gold)
+		if g.rules.PickSymbol() {
+			mark := "X"
+			if g.hint.Symbol == game.O {
+				mark = "O"
+			}
+			text.Draw(screen, "Put down "+mark, g.tinyFont, hintBtnX, hintBtnY-10, gold)
+		}
+	}
+}
+
+// drawFrame draws a thick frame just inside a cell of the board
+func (g *Game) drawFrame(screen *ebiten.Image, pos game.Pos, c color.Color) {
+	x := float64(g.offset + pos.Col*g.cellSize)
+	y := float64(g.offset + pos.Row*g.cellSize)
+	size := float64(g.cellSize)
+	for i := 2.0; i < 6; i++ {
+		ebitenutil.DrawLine(screen, x+i, y+i, x+size-i, y+i, c)
+		ebitenutil.DrawLine(screen, x+i, y+size-i, x+size-i, y+size-i, c)
+		ebitenutil.DrawLine(screen, x+i, y+i, x+i, y+size-i, c)
+		ebitenutil.DrawLine(screen, x+size-i, y+i, x+size-i, y+size-i, c)
+	}
+}
+
+// valueColor is green for a win, red for a loss and gray for a draw
+func valueColor(v game.Value) color.Color {
+	switch v.Result {
+	case 1:
+		return color.RGBA{0, 220, 0, 255}
+	case -1:
+		return color.RGBA{230, 60, 60, 255}
+	}
+	return color.RGBA{180, 180, 180, 255}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+// updateLeaderboard goes back to the menu on Escape or a click
+func (g *Game) updateLeaderboard() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) || inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		g.state = StateMenu
+	}
+}
+
+func (g *Game) drawLeaderboard(screen *ebiten.Image) {
+	screen.Fill(color.RGBA{30, 30, 30, 255})
+
+	text.Draw(screen, "Leaderboard", g.titleFont, g.mX/20, 80, color.White)
+
+	if g.leaderboard == nil {
+		text.Draw(screen, "Loading...", g.smallFont, g.mX/20, 150, color.White)
+		return
+	}
+	if len(g.leaderboard) == 0 {
+		text.Draw(screen, "Nobody has played a rated game yet", g.smallFont, g.mX/20, 150, color.White)
+	}
+
+	// Column headers
+	gray := color.RGBA{160, 160, 160, 255}
+	text.Draw(screen, "#", g.smallFont, 30, 130, gray)
+	text.Draw(screen, "Name", g.smallFont, 70, 130, gray)
+	text.Draw(screen, "Rating", g.smallFont, 320, 130, gray)
+	text.Draw(screen, "W/L/D", g.smallFont, 440, 130, gray)
+
+	for i, e := range g.leaderboard {
+		y := 170 + i*35
+
+		// highlight our own row
+		clr := color.Color(color.White)
+		if e.Username == g.username {
+			clr = color.RGBA{255, 215, 0, 255}
+		}
+
+		text.Draw(screen, fmt.Sprint(e.Rank), g.smallFont, 30, y, clr)
+		name := e.Username
+		if e.Bot {
+			name += " (bot)"
+		}
+		text.Draw(screen, name, g.smallFont, 70, y, clr)
+		text.Draw(screen, fmt.Sprint(e.Rating), g.smallFont, 320, y, clr)
+		text.Draw(screen, fmt.Sprintf("%d/%d/%d", e.Wins, e.Losses, e.Draws), g.smallFont, 440, y, clr)
+	}
+
+	text.Draw(screen, "Click or press Esc to go back", g.smallFont, g.mX/20, g.mY-30, gray)
+}
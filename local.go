@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"tictactoe/game"
+)
+
+// the Local and Bot buttons, in the menu next to Play and Ranks and under
+// Login on the login screen so they work without a server
+const (
+	localBtnW = 120
+	localBtnH = 80
+)
+
+// localBackend plays a game on this computer, doing what the server would.
+// In hot-seat everybody shares the mouse and whoever's turn it is clicks.
+type localBackend struct {
+	rules  game.Settings
+	state  game.State
+	moves  []game.Input
+	names  []string
+	events chan game.Envelope // read once a frame, room for all one move sends
+	// seat of the person at the keyboard, 0 in hot-seat where it's
+	// whoever's turn it is
+	human int
+	// no hints, the solver would give puzzles away
+	noHints bool
+}
+
+func newLocalBackend(rules game.Settings) (*localBackend, error) {
+	l := &localBackend{rules: rules, events: make(chan game.Envelope, 16)}
+	return l, l.start()
+}
+
+// start begins a new game and shows it
+func (l *localBackend) start() error {
+	state, err := l.rules.Start()
+	if err != nil {
+		return err
+	}
+	l.state = state
+	l.moves = nil
+	l.update()
+	return nil
+}
+
+func (l *localBackend) SubmitMove(in game.Input) error {
+	if l.state.Outcome() != "" {
+		return errors.New("the game is over")
+	}
+	if l.human != 0 && l.state.ToMove() != l.human {
+		return errors.New("it's not your turn")
+	}
+	if err := l.apply(in); err != nil {
+		return err
+	}
+	l.update()
+	return nil
+}
+
+// apply plays a move and remembers it for Update.Moves
+func (l *localBackend) apply(in game.Input) error {
+	if err := l.state.Apply(in); err != nil {
+		return err
+	}
+	l.moves = append(l.moves, in)
+	return nil
+}
+
+func (l *localBackend) RequestRematch() error {
+	return l.start()
+}
+
+func (l *localBackend) Chat(text string) error {
+	// nobody to send it to, but it's nice to see it
+	seat := l.human
+	if seat == 0 {
+		seat = l.state.ToMove()
+	}
+	from := fmt.Sprintf("Player %d", seat)
+	if seat <= len(l.names) {
+		from = l.names[seat-1]
+	}
+	l.send(game.TypeChat, game.Message{From: from, Text: text})
+	return nil
+}
+
+func (l *localBackend) Leave() error {
+	close(l.events)
+	return nil
+}
+
+func (l *localBackend) Events() <-chan game.Envelope {
+	return l.events
+}
+
+// update sends the game the same way the server does after a move
+func (l *localBackend) update() {
+	state, err := l.state.Save()
+	if err != nil {
+		fmt.Println("error saving game state:", err)
+	}
+	player := l.human
+	if player == 0 {
+		player = l.state.ToMove()
+	}
+	l.send(game.TypeUpdate, game.Update{
+		Player:  player,
+		Rules:   l.rules,
+		Board:   l.state.Grid().Clone(), // the click handler draws on the UI's copy
+		Turn:    l.state.CurrentTurn(),
+		ToMove:  l.state.ToMove(),
+		Winner:  l.state.Outcome(),
+		Names:   l.names,
+		Moves:   append([]game.Input{}, l.moves...),
+		NoHints: l.noHints,
+		State:   state,
+	})
+}
+
+func (l *localBackend) send(typ string, v any) {
+	env, err := game.Wrap(typ, v)
+	if err != nil {
+		fmt.Println("Send error:", err)
+		return
+	}
+	l.events <- env
+}
+
+// startLocal starts a game on this computer on the board picked in the
+// menu, hot-seat or against the bot
+func (g *Game) startLocal(bot bool) {
+	rules := g.presets()[g.preset]
+	var b Backend
+	var err error
+	if bot {
+		b, err = newBotBackend(rules, g.openingBook(rules))
+	} else {
+		b, err = newLocalBackend(rules)
+	}
+	if err != nil {
+		g.notice = err.Error()
+		return
+	}
+	g.local = b
+	g.setRules(rules)
+	g.resetBoard()
+	g.chat = nil
+	g.state = StatePlaying
+}
+
+// backend is where the game we're in is played
+func (g *Game) backend() Backend {
+	if g.local != nil {
+		return g.local
+	}
+	return g.server
+}
+
+// home is where leaving a game goes, the login screen if we haven't
+// logged in and only played locally
+func (g *Game) home() GameState {
+	if !g.loggedIn {
+		return StateLogin
+	}
+	return StateMenu
+}
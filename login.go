@@ -0,0 +1,171 @@
+package main
+
+import (
+	"image/color"
+	"os"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+
+	"tictactoe/game"
+)
+
+// the last session token the server gave us, so we don't have to type the
+// password every time the client starts
+const sessionFile = "session.txt"
+
+// positions of the login screen widgets
+const (
+	fieldX       = 100
+	fieldW       = 400
+	fieldH       = 50
+	userFieldY   = 210
+	passFieldY   = 300
+	loginBtnX    = 100
+	registerBtnX = 320
+	authBtnY     = 400
+	authBtnW     = 180
+	authBtnH     = 60
+	localBtnY    = 520
+)
+
+func inside(x, y, rx, ry, rw, rh int) bool {
+	return x >= rx && x <= rx+rw && y >= ry && y <= ry+rh
+}
+
+func (g *Game) updateLogin(x, y int) {
+	g.h_login = inside(x, y, loginBtnX, authBtnY, authBtnW, authBtnH)
+	g.h_register = inside(x, y, registerBtnX, authBtnY, authBtnW, authBtnH)
+	g.h_local = inside(x, y, loginBtnX, localBtnY, authBtnW, authBtnH)
+	g.h_bot = inside(x, y, registerBtnX, localBtnY, authBtnW, authBtnH)
+	g.h_puzzles = inside(x, y, puzzleLoginX, puzzleLoginY, puzzleLoginW, puzzleLoginH)
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		switch {
+		case inside(x, y, fieldX, userFieldY, fieldW, fieldH):
+			g.focus = 0
+		case inside(x, y, fieldX, passFieldY, fieldW, fieldH):
+			g.focus = 1
+		case g.h_login:
+			g.sendCredentials(game.TypeLogin)
+		case g.h_register:
+			g.sendCredentials(game.TypeRegister)
+		case g.h_local:
+			// no account needed, and it works when the server's down
+			g.startLocal(false)
+		case g.h_bot:
+			g.startLocal(true)
+		case g.h_puzzles:
+			g.openPuzzles()
+		}
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+		g.focus = 1 - g.focus
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		g.sendCredentials(game.TypeLogin)
+	}
+
+	// type into whichever box has focus
+	field := &g.username
+	if g.focus == 1 {
+		field = &g.password
+	}
+	for _, r := range ebiten.AppendInputChars(nil) {
+		if len(*field) < 16 && r > ' ' && r < 127 {
+			*field += string(r)
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(*field) > 0 {
+		*field = (*field)[:len(*field)-1]
+	}
+}
+
+func (g *Game) sendCredentials(typ string) {
+	if g.username == "" || g.password == "" {
+		g.authErr = "Enter a username and password"
+		return
+	}
+	g.authErr = ""
+	g.send(typ, game.Credentials{Username: g.username, Password: g.password})
+}
+
+// handleAuth runs on the game loop, from pump, with the server's answer
+func (g *Game) handleAuth(res game.AuthResult) {
+	if !res.OK {
+		g.authErr = res.Error
+		os.Remove(sessionFile)
+		return
+	}
+	g.username = res.Username
+	g.password = ""
+	g.authErr = ""
+	g.loggedIn = true
+	if g.local == nil { // don't pull them out of a local game
+		g.state = StateMenu
+	}
+	os.WriteFile(sessionFile, []byte(res.Token), 0600)
+}
+
+// resumeSession logs back in with the token saved by the last run, if any
+func (g *Game) resumeSession() {
+	data, err := os.ReadFile(sessionFile)
+	if err != nil {
+		return
+	}
+	token := strings.TrimSpace(string(data))
+	if token != "" {
+		g.send(game.TypeLogin, game.Credentials{Token: token})
+	}
+}
+
+func (g *Game) drawLogin(screen *ebiten.Image) {
+	screen.Fill(color.RGBA{30, 30, 30, 255})
+
+	text.Draw(screen, "Tic Tac Toe", g.titleFont, g.mX/4, 120, color.White)
+
+	boxColor := func(focused bool) color.Color {
+		if focused {
+			return color.RGBA{80, 80, 80, 255}
+		}
+		return color.RGBA{55, 55, 55, 255}
+	}
+
+	// Username box
+	text.Draw(screen, "Username", g.smallFont, fieldX, userFieldY-8, color.White)
+	ebitenutil.DrawRect(screen, fieldX, userFieldY, fieldW, fieldH, boxColor(g.focus == 0))
+	text.Draw(screen, g.username, g.smallFont, fieldX+10, userFieldY+35, color.White)
+
+	// Password box, never show what was typed
+	text.Draw(screen, "Password", g.smallFont, fieldX, passFieldY-8, color.White)
+	ebitenutil.DrawRect(screen, fieldX, passFieldY, fieldW, fieldH, boxColor(g.focus == 1))
+	text.Draw(screen, strings.Repeat("*", len(g.password)), g.smallFont, fieldX+10, passFieldY+35, color.White)
+
+	// Login and Register buttons
+	ebitenutil.DrawRect(screen, loginBtnX, authBtnY, authBtnW, authBtnH, buttonColor(g.h_login))
+	text.Draw(screen, "Login", g.smallFont, loginBtnX+55, authBtnY+40, color.White)
+	ebitenutil.DrawRect(screen, registerBtnX, authBtnY, authBtnW, authBtnH, buttonColor(g.h_register))
+	text.Draw(screen, "Register", g.smallFont, registerBtnX+35, authBtnY+40, color.White)
+
+	// Local and Bot buttons, for playing without logging in
+	ebitenutil.DrawRect(screen, loginBtnX, localBtnY, authBtnW, authBtnH, buttonColor(g.h_local))
+	text.Draw(screen, "Local", g.smallFont, loginBtnX+55, localBtnY+40, color.White)
+	ebitenutil.DrawRect(screen, registerBtnX, localBtnY, authBtnW, authBtnH, buttonColor(g.h_bot))
+	text.Draw(screen, "Bot", g.smallFont, registerBtnX+70, localBtnY+40, color.White)
+	g.drawPuzzleButton(screen)
+
+	if g.authErr != "" {
+		text.Draw(screen, g.authErr, g.smallFont, fieldX, authBtnY+110, color.RGBA{255, 90, 90, 255})
+	}
+}
+
+func buttonColor(hover bool) color.Color {
+	if hover {
+		return color.RGBA{100, 100, 200, 255}
+	}
+	return color.RGBA{10, 10, 255, 255}
+}
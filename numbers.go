@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"slices"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+
+	"tictactoe/game"
+)
+
+// number keys 1-9 pick the number to play in numbers
+var digitKeys = []ebiten.Key{
+	ebiten.KeyDigit1, ebiten.KeyDigit2, ebiten.KeyDigit3,
+	ebiten.KeyDigit4, ebiten.KeyDigit5, ebiten.KeyDigit6,
+	ebiten.KeyDigit7, ebiten.KeyDigit8, ebiten.KeyDigit9,
+}
+
+// numbersRenderer draws numbers instead of marks and lets us pick them
+type numbersRenderer struct{ plainRenderer }
+
+// keys reads the number keys and makes sure g.symbol is a number we still
+// have
+func (numbersRenderer) keys(g *Game) {
+	left := game.Numbers(g.board, g.player)
+	for i, key := range digitKeys {
+		if inpututil.IsKeyJustPressed(key) && slices.Contains(left, i+1) {
+			g.symbol = i + 1
+		}
+	}
+	if !slices.Contains(left, g.symbol) && len(left) > 0 {
+		g.symbol = left[0]
+	}
+}
+
+// mark writes the number in a cell instead of an X or O
+func (numbersRenderer) mark(g *Game, screen *ebiten.Image, row, col int, _, _ float64) bool {
+	n := g.board.At(row, col)
+	if n == 0 {
+		return false
+	}
+	clr := color.RGBA{255, 120, 120, 255} // odd, player 1
+	if n%2 == 0 {
+		clr = color.RGBA{120, 160, 255, 255}
+	}
+	x := g.offset + col*g.cellSize + g.cellSize/2 - 12
+	y := g.offset + row*g.cellSize + g.cellSize/2 + 16
+	text.Draw(screen, fmt.Sprint(n), g.titleFont, x, y, clr)
+	return true
+}
+
+// over shows the numbers we can still play and which one a click puts
+// down
+func (numbersRenderer) over(g *Game, screen *ebiten.Image) {
+	if g.player == 0 || g.winner != "" {
+		return
+	}
+	var left []string
+	for _, n := range game.Numbers(g.board, g.player) {
+		left = append(left, fmt.Sprint(n))
+	}
+	line := fmt.Sprintf("Yours: %s  placing %d", strings.Join(left, " "), g.symbol)
+	text.Draw(screen, line, g.tinyFont, g.mX-250, g.mY/20, color.RGBA{160, 160, 160, 255})
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// players 1 and 2 are X and O, anybody after that gets a colored square
+var pieceColors = []color.RGBA{
+	{60, 200, 90, 255},  // player 3
+	{240, 200, 40, 255}, // player 4
+}
+
+// drawPiece draws the piece of player 3 or 4 in the cell at x, y
+func (g *Game) drawPiece(screen *ebiten.Image, player int, x, y float64) {
+	i := player - 3
+	if i < 0 || i >= len(pieceColors) {
+		return
+	}
+	cell := float64(g.cellSize)
+	ebitenutil.DrawRect(screen, x+cell/5, y+cell/5, cell*3/5, cell*3/5, pieceColors[i])
+	// a darker middle so it reads as a ring like the O
+	ebitenutil.DrawRect(screen, x+cell*2/5, y+cell*2/5, cell/5, cell/5, color.RGBA{30, 30, 30, 255})
+}
@@ -1,6 +1,8 @@
-/*
+//go:build ignore
+
 package main
 
+/*
 import (
 	"context"
 	"encoding/json"
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+
+	"tictactoe/game"
+)
+
+// the puzzles, and which ones we've done so far, next to X.png
+const (
+	puzzleFile         = "puzzles.json"
+	puzzleProgressFile = "puzzles_done.json"
+)
+
+// what the progress file says about a puzzle
+const (
+	puzzleSolved = "solved"
+	puzzleFailed = "failed"
+)
+
+// the list on the puzzle screen
+const (
+	puzzleListY = 130
+	puzzleRowH  = 35
+)
+
+// the Puzzles button in the top right of the login screen, so they work
+// without a server
+const (
+	puzzleLoginX = 480
+	puzzleLoginY = 20
+	puzzleLoginW = 100
+	puzzleLoginH = 40
+)
+
+// puzzleBackend is a puzzle played on this computer. We're whoever's turn
+// it is in the puzzle and the other side answers straight away, as well
+// as it can.
+type puzzleBackend struct {
+	*localBackend
+	puzzle   game.Puzzle
+	left     int  // moves we have to win in
+	over     bool // solved or failed, R tries it again
+	finished func(solved bool)
+}
+
+func newPuzzleBackend(p game.Puzzle, finished func(solved bool)) (*puzzleBackend, error) {
+	l := &localBackend{rules: p.Rules, events: make(chan game.Envelope, 16), noHints: true}
+	b := &puzzleBackend{localBackend: l, puzzle: p, finished: finished}
+	return b, b.start()
+}
+
+// start sets up the puzzle's position. The moves that got there count as
+// the game's, so the review can go over them.
+func (b *puzzleBackend) start() error {
+	state, moves, err := b.puzzle.Start()
+	if err != nil {
+		return err
+	}
+	b.state = state
+	b.moves = moves
+	b.human = state.ToMove()
+	b.names = []string{"Puzzle", "Puzzle"}
+	b.names[b.human-1] = "You"
+	b.left = b.puzzle.In
+	b.over = false
+	b.update()
+	return nil
+}
+
+func (b *puzzleBackend) SubmitMove(in game.Input) error {
+	if b.over {
+		return errors.New("the puzzle's over, R tries it again")
+	}
+	if b.state.ToMove() != b.human {
+		return errors.New("it's not your turn")
+	}
+	keeps := b.puzzle.Keeps(b.state, in, b.left)
+	if err := b.apply(in); err != nil {
+		return err // not a move at all, that doesn't count against them
+	}
+	b.left--
+	if !keeps {
+		b.finish(false)
+		b.update()
+		return nil
+	}
+	if b.state.Outcome() == "" {
+		reply, ok := b.puzzle.Reply(b.state, b.left)
+		if !ok {
+			return errors.New("no answer to that")
+		}
+		if err := b.apply(reply); err != nil {
+			return err
+		}
+	}
+	if b.state.Outcome() != "" {
+		b.finish(true) // Keeps made sure it went our way
+	}
+	b.update()
+	return nil
+}
+
+// finish says how it went and tells the puzzle screen
+func (b *puzzleBackend) finish(solved bool) {
+	b.over = true
+	msg := "Solved!"
+	if !solved {
+		msg = "That one doesn't do it, R tries it again"
+	}
+	b.send(game.TypeChat, game.Message{Text: msg})
+	if b.finished != nil {
+		b.finished(solved)
+	}
+}
+
+func (b *puzzleBackend) RequestRematch() error {
+	return b.start()
+}
+
+// goal is what a puzzle asks for, for the list and the game screen
+func goal(p game.Puzzle) string {
+	if p.Goal == game.GoalDraw {
+		return "Save the draw"
+	}
+	return fmt.Sprintf("Win in %d", p.In)
+}
+
+// openPuzzles loads the puzzles and what we've done of them, and shows the
+// list
+func (g *Game) openPuzzles() {
+	g.puzzles, g.puzzleErr = nil, ""
+	list, err := game.LoadPuzzles(puzzleFile)
+	if err != nil {
+		g.puzzleErr = err.Error()
+	}
+	g.puzzles = list
+	g.progress = map[string]string{}
+	if data, err := os.ReadFile(puzzleProgressFile); err == nil {
+		json.Unmarshal(data, &g.progress)
+	}
+	if g.puzzleAt >= len(g.puzzles) {
+		g.puzzleAt = 0
+	}
+	g.state = StatePuzzles
+}
+
+// startPuzzle plays the selected puzzle
+func (g *Game) startPuzzle() {
+	p := g.puzzles[g.puzzleAt]
+	b, err := newPuzzleBackend(p, func(solved bool) { g.record(p.Name, solved) })
+	if err != nil {
+		g.puzzleErr = err.Error()
+		return
+	}
+	g.local = b
+	g.setRules(p.Rules)
+	g.resetBoard()
+	g.chat = nil
+	g.addChat(game.Message{Text: p.Name + ": " + goal(p)})
+	g.state = StatePlaying
+}
+
+// record saves how a puzzle went. Once it's solved it stays solved.
+func (g *Game) record(name string, solved bool) {
+	switch {
+	case solved:
+		g.progress[name] = puzzleSolved
+	case g.progress[name] != puzzleSolved:
+		g.progress[name] = puzzleFailed
+	}
+	data, err := json.MarshalIndent(g.progress, "", "\t")
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(puzzleProgressFile, data, 0644); err != nil {
+		fmt.Println("error saving puzzle progress:", err)
+	}
+}
+
+func (g *Game) updatePuzzles(x, y int) {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.state = g.home()
+		return
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyUp) && g.puzzleAt > 0 {
+		g.puzzleAt--
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyDown) && g.puzzleAt < len(g.puzzles)-1 {
+		g.puzzleAt++
+	}
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		// a row starts a line above its text
+		top := puzzleListY - 25
+		row := (y - top) / puzzleRowH
+		if y < top || row >= len(g.puzzles) {
+			return
+		}
+		g.puzzleAt = row
+		g.startPuzzle()
+		return
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) && len(g.puzzles) > 0 {
+		g.startPuzzle()
+	}
+}
+
+func (g *Game) drawPuzzles(screen *ebiten.Image) {
+	screen.Fill(color.RGBA{30, 30, 30, 255})
+	gray := color.RGBA{160, 160, 160, 255}
+
+	text.Draw(screen, "Puzzles", g.titleFont, g.mX/20, 80, color.White)
+
+	if g.puzzleErr != "" {
+		text.Draw(screen, g.puzzleErr, g.tinyFont, g.mX/20, g.mY-50, color.RGBA{255, 90, 90, 255})
+	}
+	solved := 0
+	for i, p := range g.puzzles {
+		y := puzzleListY + i*puzzleRowH
+		clr := color.Color(color.White)
+		if i == g.puzzleAt {
+			clr = color.RGBA{255, 215, 0, 255}
+		}
+		text.Draw(screen, p.Name, g.smallFont, 30, y, clr)
+		text.Draw(screen, p.Rules.Name(), g.tinyFont, 250, y, gray)
+		text.Draw(screen, goal(p), g.tinyFont, 380, y, gray)
+		switch g.progress[p.Name] {
+		case puzzleSolved:
+			solved++
+			text.Draw(screen, "solved", g.tinyFont, 500, y, color.RGBA{0, 220, 0, 255})
+		case puzzleFailed:
+			text.Draw(screen, "failed", g.tinyFont, 500, y, color.RGBA{230, 60, 60, 255})
+		}
+	}
+	if len(g.puzzles) > 0 {
+		text.Draw(screen, fmt.Sprintf("%d of %d solved", solved, len(g.puzzles)), g.smallFont, g.mX/2, 80, gray)
+	}
+	text.Draw(screen, "Enter or click plays one, Esc goes back", g.tinyFont, g.mX/20, g.mY-20, gray)
+}
+
+// drawPuzzleGoal reminds us what the puzzle on the board wants, where the
+// hint buttons would be
+func (g *Game) drawPuzzleGoal(screen *ebiten.Image) {
+	b, ok := g.local.(*puzzleBackend)
+	if !ok {
+		return
+	}
+	gold := color.RGBA{255, 215, 0, 255}
+	text.Draw(screen, "Puzzle", g.tinyFont, hintBtnX, hintBtnY+20, gold)
+	text.Draw(screen, goal(b.puzzle), g.tinyFont, hintBtnX, hintBtnY+40, gold)
+}
+
+// drawPuzzleButton is the Puzzles button on the login screen
+func (g *Game) drawPuzzleButton(screen *ebiten.Image) {
+	ebitenutil.DrawRect(screen, puzzleLoginX, puzzleLoginY, puzzleLoginW, puzzleLoginH, buttonColor(g.h_puzzles))
+	text.Draw(screen, "Puzzles", g.tinyFont, puzzleLoginX+18, puzzleLoginY+26, color.White)
+}
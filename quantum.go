@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+
+	"tictactoe/game"
+)
+
+// quantumRenderer draws the spooky marks of quantum and picks them
+type quantumRenderer struct{ plainRenderer }
+
+// quantumState is the state of the quantum game we're in, nil until the
+// server sends it
+func (g *Game) quantumState() *game.QuantumState {
+	if q, ok := g.current.(*game.QuantumGame); ok {
+		return &q.Quantum
+	}
+	return nil
+}
+
+// click handles a click on a cell when it's our turn. A spooky mark takes
+// two clicks, the first cell stays lit up until the second.
+func (quantumRenderer) click(g *Game, pos game.Pos) bool {
+	q := g.quantumState()
+	if q == nil {
+		return true
+	}
+	switch {
+	case q.Pending != -1:
+		// the other player closed a cycle, we pick where it collapses
+		g.move(game.Input{Player: g.player, Kind: game.MoveCollapse, Pos: pos})
+	case g.board.Get(pos) != 0:
+		// classical marks are final
+	case g.freeCells() == 1:
+		// the last cell just gets a normal mark
+		g.move(game.Input{Player: g.player, Pos: pos})
+	case g.spookyFrom == nil:
+		g.spookyFrom = &pos
+	case *g.spookyFrom == pos:
+		g.spookyFrom = nil
+	default:
+		g.move(game.Input{Player: g.player, Kind: game.MoveSpooky, Pos: *g.spookyFrom, Other: &pos})
+		g.spookyFrom = nil
+	}
+	return true
+}
+
+func (g *Game) freeCells() int {
+	n := 0
+	for _, v := range g.board.Cells {
+		if v == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// markName is how a mark is written, like X3 for X's move on turn 3
+func markName(player, move int) string {
+	if player == 1 {
+		return fmt.Sprint("X", move)
+	}
+	return fmt.Sprint("O", move)
+}
+
+// over writes the spooky marks into their cells, the turn under every
+// classical mark and lights up the cells that can be picked
+func (quantumRenderer) over(g *Game, screen *ebiten.Image) {
+	q := g.quantumState()
+	if q == nil {
+		return
+	}
+	gray := color.RGBA{160, 160, 160, 255}
+	cell := float64(g.cellSize)
+	corner := func(p game.Pos) (float64, float64) {
+		return float64(g.offset + p.Col*g.cellSize), float64(g.offset + p.Row*g.cellSize)
+	}
+
+	if g.spookyFrom != nil {
+		x, y := corner(*g.spookyFrom)
+		ebitenutil.DrawRect(screen, x+2, y+2, cell-4, cell-4, color.RGBA{60, 60, 20, 160})
+	}
+	if q.Pending != -1 {
+		for _, p := range q.Marks[q.Pending].Cells {
+			x, y := corner(p)
+			ebitenutil.DrawRect(screen, x+2, y+2, cell-4, cell-4, color.RGBA{90, 60, 0, 160})
+		}
+	}
+
+	// spooky marks fill a cell left to right, three to a line
+	count := map[game.Pos]int{}
+	for _, m := range q.Marks {
+		clr := color.RGBA{255, 120, 120, 255}
+		if m.Player == 2 {
+			clr = color.RGBA{120, 160, 255, 255}
+		}
+		for _, p := range m.Cells {
+			x, y := corner(p)
+			k := count[p]
+			count[p]++
+			text.Draw(screen, markName(m.Player, m.Move), g.tinyFont, int(x)+8+k%3*(g.cellSize/3), int(y)+22+k/3*(g.cellSize/4), clr)
+		}
+	}
+
+	for i, move := range q.Moves {
+		if move != 0 {
+			p := game.Pos{Row: i / g.board.Cols, Col: i % g.board.Cols}
+			x, y := corner(p)
+			text.Draw(screen, fmt.Sprint(move), g.tinyFont, int(x+cell)-22, int(y+cell)-8, gray)
+		}
+	}
+
+	if g.winner != "" || g.player == 0 {
+		return
+	}
+	myTurn := g.toMove == g.player
+	hint := ""
+	switch {
+	case q.Pending != -1 && myTurn:
+		m := q.Marks[q.Pending]
+		hint = "Pick where " + markName(m.Player, m.Move) + " goes"
+	case q.Pending != -1:
+		hint = "Waiting for a collapse"
+	case myTurn && g.spookyFrom != nil:
+		hint = "Now pick the second cell"
+	}
+	text.Draw(screen, hint, g.tinyFont, g.mX-220, g.mY/20, gray)
+}
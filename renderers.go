@@ -0,0 +1,95 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+
+	"tictactoe/game"
+)
+
+// renderer is how the client shows a variant on top of the plain grid.
+// Variants without one are just X and O on the board.
+type renderer interface {
+	// keys reads the variant's own keys while we're playing
+	keys(g *Game)
+	// click gets a click on a cell when it's our turn. It returns false to
+	// leave it to the normal move.
+	click(g *Game, pos game.Pos) bool
+	// under is drawn before the grid and over after the marks
+	under(g *Game, screen *ebiten.Image)
+	over(g *Game, screen *ebiten.Image)
+	// mark draws what's in a cell, false draws the normal X or O
+	mark(g *Game, screen *ebiten.Image, row, col int, x, y float64) bool
+}
+
+// renderers by variant name, the same names the game package registers
+// the rules under
+var renderers = map[string]renderer{
+	game.VariantUltimate: ultimateRenderer{},
+	game.VariantQuantum:  quantumRenderer{},
+	game.VariantNumbers:  numbersRenderer{},
+	game.VariantNotakto:  notaktoRenderer{},
+	game.VariantWild:     symbolRenderer{},
+	game.VariantOrder:    symbolRenderer{},
+}
+
+// renderer finds the renderer for the game we're in
+func (g *Game) renderer() renderer {
+	if r, ok := renderers[g.rules.Variant]; ok {
+		return r
+	}
+	return plainRenderer{}
+}
+
+// plainRenderer doesn't add anything, the others embed it so they only
+// have to write what they change
+type plainRenderer struct{}
+
+func (plainRenderer) keys(g *Game)                                               {}
+func (plainRenderer) click(g *Game, pos game.Pos) bool                           { return false }
+func (plainRenderer) under(g *Game, screen *ebiten.Image)                        {}
+func (plainRenderer) over(g *Game, screen *ebiten.Image)                         {}
+func (plainRenderer) mark(*Game, *ebiten.Image, int, int, float64, float64) bool { return false }
+
+// notaktoRenderer draws everybody's marks as X
+type notaktoRenderer struct{ plainRenderer }
+
+func (notaktoRenderer) mark(g *Game, screen *ebiten.Image, row, col int, x, y float64) bool {
+	if g.board.At(row, col) == 0 {
+		return false
+	}
+	g.drawImage(screen, g.imageX, x, y, float64(g.cellSize))
+	return true
+}
+
+// symbolRenderer is for wild and order and chaos, where S swaps between
+// putting down an X or an O
+type symbolRenderer struct{ plainRenderer }
+
+func (symbolRenderer) keys(g *Game) {
+	if inpututil.IsKeyJustPressed(ebiten.KeyS) {
+		g.symbol = 3 - g.symbol
+	}
+}
+
+func (symbolRenderer) over(g *Game, screen *ebiten.Image) {
+	if g.player == 0 || g.winner != "" {
+		return
+	}
+	mark := "X"
+	if g.symbol == game.O {
+		mark = "O"
+	}
+	text.Draw(screen, "You place "+mark+", S swaps", g.tinyFont, g.mX-190, g.mY/20, color.RGBA{160, 160, 160, 255})
+}
+
+// drawImage draws img size pixels square at x, y
+func (g *Game) drawImage(screen, img *ebiten.Image, x, y, size float64) {
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(size/float64(img.Bounds().Dx()), size/float64(img.Bounds().Dy()))
+	op.GeoM.Translate(x, y)
+	screen.DrawImage(img, op)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+
+	"tictactoe/game"
+)
+
+// the move list down the strip right of the board
+const (
+	reviewListY = 120
+	reviewLineH = 18
+)
+
+// updateReviewButton starts the review once the game's over, with E or the
+// Review button where Hint was. It returns true when it used the click.
+func (g *Game) updateReviewButton(x, y int) bool {
+	if g.winner == "" || !game.Solvable(g.rules) || len(g.moves) == 0 {
+		return false
+	}
+	g.h_review = inside(x, y, hintBtnX, hintBtnY, hintBtnW, hintBtnH)
+	click := inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && g.h_review
+	if click || inpututil.IsKeyJustPressed(ebiten.KeyE) {
+		g.startReview()
+	}
+	return click
+}
+
+// startReview goes over the game that just finished with the solver
+func (g *Game) startReview() {
+	states, err := game.Replay(g.rules, g.moves)
+	if err != nil {
+		g.addChat(game.Message{Text: "Can't review this game: " + err.Error()})
+		return
+	}
+	review, err := g.solver().Review(g.moves)
+	if err != nil {
+		g.addChat(game.Message{Text: "Can't review this game: " + err.Error()})
+		return
+	}
+	g.review = review
+	g.reviewStates = states
+	g.reviewBoard = g.board
+	g.reviewAt = len(g.moves) // starts at the end, where the game is
+	g.state = StateReview
+}
+
+// updateReview steps through the game with the arrow keys. The board shows
+// the game after the first reviewAt moves.
+func (g *Game) updateReview(x, y int) {
+	g.h_review = inside(x, y, hintBtnX, hintBtnY, hintBtnW, hintBtnH)
+	back := inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && g.h_review
+	switch {
+	case back || inpututil.IsKeyJustPressed(ebiten.KeyEscape) || inpututil.IsKeyJustPressed(ebiten.KeyE):
+		g.board = g.reviewBoard
+		g.review, g.reviewStates = nil, nil
+		g.state = StatePlaying
+		return
+	case inpututil.IsKeyJustPressed(ebiten.KeyLeft) && g.reviewAt > 0:
+		g.reviewAt--
+	case inpututil.IsKeyJustPressed(ebiten.KeyRight) && g.reviewAt < len(g.review):
+		g.reviewAt++
+	case inpututil.IsKeyJustPressed(ebiten.KeyHome):
+		g.reviewAt = 0
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnd):
+		g.reviewAt = len(g.review)
+	}
+	g.board = g.reviewStates[g.reviewAt].Grid()
+}
+
+func (g *Game) drawReview(screen *ebiten.Image) {
+	gray := color.RGBA{160, 160, 160, 255}
+	g.drawBoard(screen)
+
+	ebitenutil.DrawRect(screen, hintBtnX, hintBtnY, hintBtnW, hintBtnH, buttonColor(g.h_review))
+	text.Draw(screen, "Back", g.tinyFont, hintBtnX+22, hintBtnY+26, color.White)
+
+	// every move, the one on the board now marked
+	for i, a := range g.review {
+		line := fmt.Sprintf("%2d %s", i+1, game.FormatMove(g.rules, a.Move))
+		if i == g.reviewAt-1 {
+			line = ">" + line
+		}
+		text.Draw(screen, line, g.tinyFont, hintBtnX, reviewListY+i*reviewLineH, labelColor(a.Label))
+	}
+
+	if g.reviewAt == 0 {
+		text.Draw(screen, "Before the first move", g.smallFont, g.mX/20, g.mY/20, color.White)
+	} else {
+		a := g.review[g.reviewAt-1]
+		if pos, ok := changedCell(g.reviewStates[g.reviewAt-1].Grid(), g.board); ok {
+			g.drawFrame(screen, pos, labelColor(a.Label))
+		}
+		title := fmt.Sprintf("Move %d of %d, %s", g.reviewAt, len(g.review), a.Label)
+		text.Draw(screen, title, g.smallFont, g.mX/20, g.mY/20, labelColor(a.Label))
+
+		who := g.name(a.Move.Player)
+		y := chatTop + chatLineH
+		if a.Changed() {
+			// where the game was lost (or thrown away)
+			text.Draw(screen, fmt.Sprintf("%s turned a %s into a %s", who, result(a.Before), result(a.After)), g.tinyFont, 10, y, labelColor(a.Label))
+			y += chatLineH
+		}
+		text.Draw(screen, fmt.Sprintf("After it, %s for %s", a.After, who), g.tinyFont, 10, y, gray)
+		y += chatLineH
+		if a.Label != game.LabelBest {
+			text.Draw(screen, fmt.Sprintf("Best was %s, %s", game.FormatMove(g.rules, a.Best), a.Before), g.tinyFont, 10, y, gray)
+			g.drawFrame(screen, a.Best.Pos, color.RGBA{255, 215, 0, 255})
+		}
+	}
+	text.Draw(screen, "Left and Right step through the game, Esc goes back", g.tinyFont, 10, g.mY-8, gray)
+}
+
+// changedCell is the cell a move went in, the one that's different
+func changedCell(before, after game.Board) (game.Pos, bool) {
+	for row := 0; row < after.Rows; row++ {
+		for col := 0; col < after.Cols; col++ {
+			if before.At(row, col) != after.At(row, col) {
+				return game.Pos{Row: row, Col: col}, true
+			}
+		}
+	}
+	return game.Pos{}, false
+}
+
+// result is how a Value ends, without how long it takes
+func result(v game.Value) string {
+	switch v.Result {
+	case 1:
+		return "win"
+	case -1:
+		return "loss"
+	}
+	return "draw"
+}
+
+// labelColor is green for the best move, yellow for an inaccuracy and red
+// for a blunder
+func labelColor(label string) color.Color {
+	switch label {
+	case game.LabelInaccuracy:
+		return color.RGBA{255, 215, 0, 255}
+	case game.LabelBlunder:
+		return color.RGBA{230, 60, 60, 255}
+	}
+	return color.RGBA{0, 220, 0, 255}
+}
+
+// drawReviewButton offers the review under the winner, where Hint was
+func (g *Game) drawReviewButton(screen *ebiten.Image) {
+	if g.winner == "" || !game.Solvable(g.rules) || len(g.moves) == 0 {
+		return
+	}
+	ebitenutil.DrawRect(screen, hintBtnX, hintBtnY, hintBtnW, hintBtnH, buttonColor(g.h_review))
+	text.Draw(screen, "Review", g.tinyFont, hintBtnX+12, hintBtnY+26, color.White)
+}
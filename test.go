@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"image/color"
 	_ "image/png"
@@ -18,6 +17,8 @@ import (
 
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/opentype"
+
+	"tictactoe/game"
 )
 
 // Creates a new data type named GameState and assigns it as a baseline int
@@ -26,16 +27,34 @@ type GameState int
 const ( // Creates constant values for GameState
         StateMenu    GameState = iota //Automatically assigns numbers starting from 0 under this constant
         StatePlaying                  // GameState = 0, State Playing = 1
+        StateLogin                    // username/password screen shown before the menu
+        StateLeaderboard              // top rated players, opened from the menu
+        StateTournaments              // tournament list and standings
+        StateReview                   // going back over a finished game
+        StatePuzzles                  // the puzzle list, see puzzles.go
 )
 
 // Defines types that will be shared accross multiple funcitions by using a pointer
 type Game struct {
-        board    [3][3]int // 0=empty, 1=X, 2=O
+        board    game.Board // 0=empty, 1=X, 2=O
+        rules    game.Settings // board size and how many in a row the current game needs
+        preset   int // board picked in the menu, index into g.presets()
+        customs  []game.CustomRules // variants the server loaded from files
+        current  game.State // the game as the server last sent it, nil before that
+        local    Backend // game on this computer, nil when playing on the server
+        h_local  bool
+        h_bot    bool
+        loggedIn bool // we've got an account on the server, so there's a menu to go back to
+        symbol   int // X or O we put down in variants where you pick, S swaps
+        spookyFrom *game.Pos // first cell of the spooky mark we're putting down
+        dropping  *game.Pos // piece falling into place in a gravity game
+        dropStart time.Time
         playing  bool
         h_play   bool // hover for playing
         h_quit   bool
         player   int // 1=X, 2=O
         turn     int
+        toMove   int // seat whose turn it is
         cellSize int
         offset   int
         wins     int
@@ -48,24 +67,56 @@ type Game struct {
 		imageX, imageO       *ebiten.Image
 		titleFont, smallFont font.Face
         state    GameState //Defines state as a GameState data type
-        conn     net.Conn
-}
-
-type Input struct {
-        Player int
-        Row    int
-        Col    int
-}
-
-type Message struct {
-        Text string 
-}
-
-type Update struct {
-        Player int
-        Board  [3][3]int
-        Turn   int
-        Winner string
+        server   *networkBackend // nil if we couldn't connect
+        names    []string // account names of player 1 and player 2
+        bots     []bool // which of them are bot accounts
+
+        // the solver's help, see hints.go
+        solvers      map[game.Settings]*game.Solver
+        book         *game.Book // from book.json, see openingBook
+        bookTried    bool
+        hint         *game.Input // best move, shown until the next move
+        showValues   bool
+        values       []game.MoveValue // what every move is worth in valuesOf
+        valuesOf     game.State
+        noHints      bool // the server turned them off
+        h_hint, h_values bool
+
+        // going back over the last game, see review.go
+        moves        []game.Input // every move of the game we're in
+        review       []game.Annotation
+        reviewStates []game.State // the game before each move and after the last
+        reviewAt     int          // how many moves the board shows
+        reviewBoard  game.Board   // the real board while the review has g.board
+        h_review     bool
+
+        // puzzles, see puzzles.go
+        puzzles      []game.Puzzle
+        puzzleAt     int               // puzzle highlighted in the list
+        puzzleErr    string            // why the file wouldn't load
+        progress     map[string]string // puzzle name to solved or failed
+        h_puzzles    bool
+
+        // login screen
+        username, password   string
+        focus                int // 0=username box, 1=password box
+        authErr              string
+        h_login, h_register  bool
+
+        h_ranks      bool
+        leaderboard  []game.LeaderboardEntry // nil until the server answers
+
+        h_cups       bool
+        tournaments  []game.TournamentInfo
+        selected     int         // tournament highlighted in the list
+        match        *game.Match // tournament game waiting for us, if any
+        notice       string      // last error from the server
+        tinyFont     font.Face
+
+        // chat for the room we're in
+        chat         []game.Message
+        chatInput    string
+        typing       bool // chat box is open, keys go to it
 }
 
 // Constructor
@@ -75,9 +126,13 @@ func NewGame() *Game {
                 h_play:   false,
                 h_quit:   false,
                 player:   1,
-                cellSize: 150,
+                symbol:   game.X,
+                board:    game.Classic.NewBoard(),
+                rules:    game.Classic,
+                cellSize: boardPixels / 3,
                 offset:   50,
                 turn:     1,
+                toMove:   1,
                 mX:       600,
                 mY:       600,
                 team:     "X",
@@ -87,6 +142,7 @@ func NewGame() *Game {
 
 
 func (g *Game) Update() error {
+        g.pump()
 
         if !g.playing {
                 return nil
@@ -96,61 +152,141 @@ func (g *Game) Update() error {
 
         switch g.state { //Switch is basically like a giant easier to use if/else statement
 
+        case StateLogin:
+                g.updateLogin(x, y)
+
         case StateMenu: //equivalent to if g.state == "StateMenu"
                 x, y := ebiten.CursorPosition()
 
                 btnWidth := 240
 				btnHeight := 80
 				btnX := g.mX/2 - 120 // Centered button X
-				btnY := g.mY/2 - 60 // Centered button Y
-				btnY2 := g.mY/2 + 80
-
-                // Hover Play Check
-                if x >= btnX && x <= btnX+btnWidth {
-                        if y >= btnY && y <= btnY+btnHeight {
-                                g.h_play = true
-                        } else if y >= btnY2 && y <= btnY2+btnHeight {
-                                g.h_quit = true
-                        } else {
-                                g.h_play = false
-                                g.h_quit = false
-                        }
+				btnY := g.mY/2 - 120 // Centered button Y
+				btnY2 := g.mY/2 + 180
+				btnY3 := g.mY/2 - 20 // Ranks and Cups buttons sit between Play and Quit
+				btnY4 := g.mY/2 + 80
+
+                // Hover Check
+                g.h_play = inside(x, y, btnX, btnY, btnWidth, btnHeight)
+                g.h_quit = inside(x, y, btnX, btnY2, btnWidth, btnHeight)
+                g.h_ranks = inside(x, y, btnX, btnY3, btnWidth, btnHeight)
+                g.h_cups = inside(x, y, btnX, btnY4, btnWidth, btnHeight)
+                g.h_local = inside(x, y, btnX+btnWidth+20, btnY, localBtnW, localBtnH)
+                g.h_bot = inside(x, y, btnX+btnWidth+20, btnY3, localBtnW, localBtnH)
+                g.h_puzzles = inside(x, y, btnX+btnWidth+20, btnY4, localBtnW, localBtnH)
+
+                // B cycles through the board sizes
+                if inpututil.IsKeyJustPressed(ebiten.KeyB) {
+                        g.preset = (g.preset + 1) % len(g.presets())
                 }
 
                 // Click Check
                 if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
-                        if x >= btnX && x <= btnX+btnWidth {
-                                if y >= btnY && y <= btnY+btnHeight {
-                                        g.state = StatePlaying
-                                } else if y >= btnY2 && y <= btnY2+btnHeight {
-                                        os.Exit(0)
-                                }
+                        if g.h_play {
+                                // room 0 is quick match, the server finds us an opponent
+                                g.send(game.TypeJoin, game.Join{Rules: g.presets()[g.preset]})
+                                g.state = StatePlaying
+                        } else if g.h_ranks {
+                                g.leaderboard = nil
+                                g.send(game.TypeLeaderboard, nil)
+                                g.state = StateLeaderboard
+                        } else if g.h_local {
+                                g.startLocal(false)
+                        } else if g.h_bot {
+                                g.startLocal(true)
+                        } else if g.h_puzzles {
+                                g.openPuzzles()
+                        } else if g.h_cups {
+                                g.send(game.TypeTournaments, nil)
+                                g.state = StateTournaments
+                        } else if g.h_quit {
+                                os.Exit(0)
                         }
                 }
 
+        case StateLeaderboard:
+                g.updateLeaderboard()
+
+        case StateTournaments:
+                g.updateTournaments()
+
+        case StateReview:
+                g.updateReview(x, y)
+
+        case StatePuzzles:
+                g.updatePuzzles(x, y)
+
         case StatePlaying: //else if g.state == "StatePlaying"
+			// while the chat box is open the keyboard belongs to it
+			if g.updateChat() {
+				break
+			}
+			if g.updateHints(x, y) || g.updateReviewButton(x, y) {
+				break
+			}
+
+			// spectators can leave whenever they like
+			if (g.player == 0 || g.local != nil) && inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+				_, puzzle := g.local.(*puzzleBackend)
+				g.backend().Leave()
+				g.local = nil
+				g.resetBoard()
+				g.state = g.home()
+				if puzzle {
+					g.state = StatePuzzles // back to the list for the next one
+				}
+				break
+			}
+
+			// variants like wild have keys for picking what to put down
+			g.renderer().keys(g)
+
 			if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && g.winner == "" {
 
 					col := (x - g.offset) / g.cellSize
 					row := (y - g.offset) / g.cellSize
+					if x < g.offset || y < g.offset { // would round to 0 otherwise
+						row, col = -1, -1
+					}
+					pos := game.Pos{Row: row, Col: col}
+					if g.rules.Gravity && g.board.In(row, col) {
+						// only the column counts, the piece falls to the bottom
+						if dropRow, ok := g.board.Drop(col); ok {
+							pos.Row = dropRow
+						} else {
+							pos.Row = -1 // column's full
+						}
+					}
+					if g.board.Layers > 1 {
+						var ok bool
+						if pos, ok = g.cubeCell(x, y); !ok {
+							pos.Layer = -1 // not on any layer
+						}
+					}
 					
 				// check player number before accepting any input
-				expectedPlayer := 1 
-				if g.turn % 2 == 0 {
-					expectedPlayer = 2
-				}
+				expectedPlayer := g.toMove
 
 				// only process the click if the expected player is the current player
 				if expectedPlayer == g.player {
+					// Click inside board
+					if g.board.Contains(pos) && g.renderer().click(g, pos) {
+						// the variant sent the move itself
+					} else if g.board.Contains(pos) {
 					// send player input to server
-						input := Input{Player: g.player, Row: row, Col: col}
-						json.NewEncoder(g.conn).Encode(input) // Sends the player that made the move and the row and col that they made the move on
+						input := game.Input{Player: g.player, Pos: pos}
+						mark := g.player
+						if g.rules.PickSymbol() {
+							input.Symbol = g.symbol
+							mark = g.symbol
+						}
+						g.move(input) // Sends the player that made the move and the row and col that they made the move on
 															// sends it something like {Player: int value, Col: int value, Col: int value} 
 			
-					// Click inside board
-					if col >= 0 && col < 3 && row >= 0 && row < 3 {
-						if g.board[row][col] == 0 {
-							g.board[row][col] = g.player
+						if g.board.Get(pos) == 0 && g.rules.Check(g.board, pos) == nil {
+							before := g.board.Clone()
+							g.board.Put(pos, mark)
+							g.startDrop(before, g.board)
 
 							// Alternate turns
 							if g.player == 1 {
@@ -161,9 +297,7 @@ func (g *Game) Update() error {
 								g.team = "O"
 							}
 
-							if g.turn >= 5 {
-								g.checkWin()
-							}
+							g.checkWin()
 									
 
 							}
@@ -175,26 +309,14 @@ func (g *Game) Update() error {
         
 
                 if g.winner != "" { // Resets game if you press R
-					if inpututil.IsKeyJustPressed(ebiten.KeyR) {
-						// Keep assets that don't need reloading
-						titleFont := g.titleFont
-						smallFont := g.smallFont
-						imageX := g.imageX
-						imageO := g.imageO
-
-						encoder := json.NewEncoder(g.conn)
-						if err := encoder.Encode(1); err != nil {
-							fmt.Println(err)
+					if inpututil.IsKeyJustPressed(ebiten.KeyR) && g.player != 0 {
+						// Reset game logic. Assets, the connection and the
+						// login all stay, we're still the same player
+						g.resetBoard()
+						g.backend().RequestRematch()
+						if g.local == nil {
+							g.state = g.home()
 						}
-						
-						// Reset game logic
-						*g = *NewGame()
-
-						// Restore assets
-						g.titleFont = titleFont
-						g.smallFont = smallFont
-						g.imageX = imageX
-						g.imageO = imageO
 					}
                 }
 
@@ -202,57 +324,157 @@ func (g *Game) Update() error {
         return nil
 }
 
-func (g *Game) checkWin() {
-    cell := float64(g.cellSize)
-	offset := float64(g.offset)
-	// Rows
-	for i := 0; i < 3; i++ {
-		if g.board[i][0] == g.board[i][1] && g.board[i][1] == g.board[i][2] && g.board[i][0] != 0 {
-			g.winner = g.team
-			g.winStartX = offset
-			g.winStartY = offset + cell*float64(i) + cell/2
-			g.winEndX = offset + 3*cell
-			g.winEndY = g.winStartY
-			return
-		}
+// pump handles whatever the backends have sent since the last frame. It
+// runs at the start of Update so only ebiten's loop ever changes the game,
+// Draw never sees a board halfway through being replaced.
+func (g *Game) pump() {
+	if g.server != nil {
+		g.drain(g.server)
+	}
+	if g.local != nil {
+		g.drain(g.local)
 	}
+}
 
-	// Columns
-	for i := 0; i < 3; i++ {
-		if g.board[0][i] == g.board[1][i] && g.board[1][i] == g.board[2][i] && g.board[0][i] != 0 {
-			g.winner = g.team
-			g.winStartX = offset + cell*float64(i) + cell/2
-			g.winStartY = offset
-			g.winEndX = g.winStartX
-			g.winEndY = offset + 3*cell
+// drain handles the events b has waiting without waiting for more. Updates
+// only count from the backend of the game we're in, the server can still be
+// sending them for a room we left for a local game.
+func (g *Game) drain(b Backend) {
+	for {
+		select {
+		case env, ok := <-b.Events():
+			if !ok {
+				return // closed, the game or the connection is over
+			}
+			if env.Type == game.TypeUpdate && b != g.backend() {
+				continue
+			}
+			g.handle(env)
+		default:
 			return
 		}
 	}
+}
 
-	// Diagonal (top-left → bottom-right)
-	if g.board[0][0] == g.board[1][1] && g.board[1][1] == g.board[2][2] && g.board[0][0] != 0 {
-		g.winner = g.team
-		g.winStartX = offset
-		g.winStartY = offset
-		g.winEndX = offset + 3*cell
-		g.winEndY = offset + 3*cell
-		return
+// handle is one message from the server, or from a local game pretending
+// to be one
+func (g *Game) handle(env game.Envelope) {
+	switch env.Type {
+	case game.TypeAuth:
+		var res game.AuthResult
+		env.Open(&res)
+		g.handleAuth(res)
+
+	case game.TypeWelcome:
+		var welcome game.Welcome
+		env.Open(&welcome)
+		// player number is the same as saying player id
+		fmt.Println("You are Player: ", welcome.Player)
+		g.player = welcome.Player
+		g.names = welcome.Names
+		g.setRules(welcome.Rules)
+		g.chat = nil // the server sends the room's chat next
+
+	case game.TypeUpdate:
+		var update game.Update
+		env.Open(&update)
+		g.applyUpdate(update) // drain already dropped ones from a game we left
+
+	case game.TypeVariants:
+		var list game.Variants
+		env.Open(&list)
+		for _, rules := range list.List {
+			// the same rules as the server so Load works on them
+			if err := rules.Register(); err != nil {
+				fmt.Println("Variant error:", err)
+				continue
+			}
+			g.customs = append(g.customs, rules)
+		}
+
+	case game.TypeLeaderboard:
+		var board game.Leaderboard
+		env.Open(&board)
+		g.leaderboard = board.Entries
+
+	case game.TypeTournaments:
+		var list game.Tournaments
+		env.Open(&list)
+		g.tournaments = list.List
+
+	case game.TypeMatch:
+		var match game.Match
+		env.Open(&match)
+		g.match = &match
+
+	case game.TypeChat:
+		var m game.Message
+		env.Open(&m)
+		g.addChat(m)
+
+	case game.TypeError:
+		var e game.Error
+		env.Open(&e)
+		fmt.Println("Server error:", e.Text)
+		if g.state == StateLogin {
+			g.authErr = e.Text
+		} else if g.state == StatePlaying {
+			// shows up in the chat panel, that's where it's usually about
+			g.addChat(game.Message{Text: e.Text})
+		} else {
+			g.notice = e.Text
+		}
 	}
+}
 
-	// Diagonal (top-right → bottom-left)
-	if g.board[0][2] == g.board[1][1] && g.board[1][1] == g.board[2][0] && g.board[0][2] != 0 {
-		g.winner = g.team
-		g.winStartX = offset + 3*cell
-		g.winStartY = offset
-		g.winEndX = offset
-		g.winEndY = offset + 3*cell
+// checkWin works out where to draw the line through a winning row. Who won
+// is up to the server, it tells us in Winner.
+func (g *Game) checkWin() {
+	if g.board.Layers > 1 {
+		return // drawCube lights up the cells instead
+	}
+	if g.rules.K == 0 {
+		return // house rules that only win with shapes, no line to draw
+	}
+	board := g.board
+	if g.rules.Variant == game.VariantNotakto {
+		board = board.Marks() // everybody's X
+	}
+	line, ok := board.FindLine(g.rules.K)
+	if g.rules.Variant == game.VariantNumbers {
+		line, ok = game.SumLine(board)
+	}
+	cell := float64(g.cellSize)
+	if u := g.ultimateState(); u != nil {
+		// the line goes through small boards, which are 3 cells wide
+		line, ok = u.Meta().FindLine(3)
+		cell *= 3
+	}
+	if !ok {
 		return
 	}
 
-	// Cat (Tie)
-	if g.turn > 9 && g.winner == "" {
-		g.winner = "CAT"
+	offset := float64(g.offset)
+
+	// which way the line goes, so it can stick out half a cell past the
+	// centers of the end cells and reach the edges like on the 3x3 board
+	dRow := float64(sign(line.To.Row - line.From.Row))
+	dCol := float64(sign(line.To.Col - line.From.Col))
+
+	g.winStartX = offset + cell*float64(line.From.Col) + cell/2 - dCol*cell/2
+	g.winStartY = offset + cell*float64(line.From.Row) + cell/2 - dRow*cell/2
+	g.winEndX = offset + cell*float64(line.To.Col) + cell/2 + dCol*cell/2
+	g.winEndY = offset + cell*float64(line.To.Row) + cell/2 + dRow*cell/2
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
 	}
+	return 0
 }
 
 func loadFont(filePath string, size float64) (font.Face, error) {
@@ -296,6 +518,9 @@ func (g *Game) Draw(screen *ebiten.Image) {
 
 	switch g.state {
 
+	case StateLogin:
+		g.drawLogin(screen)
+
 	case StateMenu:
 		// Draw background
 		screen.Fill(color.RGBA{30, 30, 30, 255})
@@ -304,28 +529,132 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		text.Draw(screen, "Tic Tac Toe", g.titleFont, g.mX/4, g.mY/4, color.White)
 
 		// Draw Play button rectangle
-		ebitenutil.DrawRect(screen, float64(g.mX/2-120), float64(g.mY/2-60), 240, 80, pColor)
-		text.Draw(screen, "Play", g.titleFont, g.mX/2-65, g.mY/2-5, color.White)
+		ebitenutil.DrawRect(screen, float64(g.mX/2-120), float64(g.mY/2-120), 240, 80, pColor)
+		text.Draw(screen, "Play", g.titleFont, g.mX/2-65, g.mY/2-65, color.White)
+
+		// Draw Local button, two players on this computer
+		ebitenutil.DrawRect(screen, float64(g.mX/2+140), float64(g.mY/2-120), localBtnW, localBtnH, buttonColor(g.h_local))
+		text.Draw(screen, "Local", g.smallFont, g.mX/2+162, g.mY/2-72, color.White)
+
+		// Draw Bot button, a local game against the computer
+		ebitenutil.DrawRect(screen, float64(g.mX/2+140), float64(g.mY/2-20), localBtnW, localBtnH, buttonColor(g.h_bot))
+		text.Draw(screen, "Bot", g.smallFont, g.mX/2+177, g.mY/2+28, color.White)
+
+		// Draw Puzzle button, positions to solve against the computer
+		ebitenutil.DrawRect(screen, float64(g.mX/2+140), float64(g.mY/2+80), localBtnW, localBtnH, buttonColor(g.h_puzzles))
+		text.Draw(screen, "Puzzle", g.smallFont, g.mX/2+160, g.mY/2+128, color.White)
+
+		// Draw Ranks button rectangle
+		ebitenutil.DrawRect(screen, float64(g.mX/2-120), float64(g.mY/2-20), 240, 80, buttonColor(g.h_ranks))
+		text.Draw(screen, "Ranks", g.titleFont, g.mX/2-80, g.mY/2+35, color.White)
+
+		// Draw Cups (tournaments) button rectangle
+		ebitenutil.DrawRect(screen, float64(g.mX/2-120), float64(g.mY/2+80), 240, 80, buttonColor(g.h_cups))
+		text.Draw(screen, "Cups", g.titleFont, g.mX/2-65, g.mY/2+135, color.White)
 
 		// Draw Quit button rectangle
-		ebitenutil.DrawRect(screen, float64(g.mX/2-120), float64(g.mY/2+80), 240, 80, qColor)
-		text.Draw(screen, "Quit", g.titleFont, g.mX/2-55, g.mY/2+135, color.White)
+		ebitenutil.DrawRect(screen, float64(g.mX/2-120), float64(g.mY/2+180), 240, 80, qColor)
+		text.Draw(screen, "Quit", g.titleFont, g.mX/2-55, g.mY/2+235, color.White)
+
+		// Which board Play (and new tournaments) will use
+		text.Draw(screen, "Board: "+g.presets()[g.preset].Name()+"  (B to change)", g.tinyFont, g.mX/20, g.mY-10, color.RGBA{160, 160, 160, 255})
+		if i := g.preset - len(game.Presets); i >= 0 && g.customs[i].Description != "" {
+			// house rules say what they are, the name isn't much to go on
+			text.Draw(screen, g.customs[i].Description, g.tinyFont, g.mX/20, g.mY-50, color.RGBA{160, 160, 160, 255})
+		}
+
+		// Tell the player when a tournament game is waiting for them
+		if g.match != nil {
+			text.Draw(screen, fmt.Sprintf("Your %s game vs %s is ready, open Cups!", g.match.Name, g.match.Opponent), g.tinyFont, g.mX/20, g.mY-30, color.RGBA{255, 215, 0, 255})
+		}
+
+	case StateTournaments:
+		g.drawTournaments(screen)
+
+	case StateLeaderboard:
+		g.drawLeaderboard(screen)
+
+	case StateReview:
+		g.drawReview(screen)
+
+	case StatePuzzles:
+		g.drawPuzzles(screen)
 
 	case StatePlaying:
+		g.drawBoard(screen)
+	
+		g.drawChat(screen)
+
+		// Draws line through winner
+		if g.winner != "" && g.winner != "CAT" && g.board.Layers <= 1 && g.rules.K > 0 {
+			for i := 0; i <= 5; i++ {
+				ebitenutil.DrawLine(
+					screen,
+					g.winStartX+float64(i),
+					g.winStartY,
+					g.winEndX+float64(i),
+					g.winEndY,
+					color.RGBA{0, 255, 0, 255}, //green
+				)
+			}
+		}
+
+		// Writes winner
+		if g.winner != "" {
+			text.Draw(screen, g.winnerName()+" Wins! Press 'R' to play again!", g.smallFont, g.mX/20, g.mY/20, color.White)
+			g.drawReviewButton(screen)
+			return
+		}
+
+		// Writes out turns
+		text.Draw(screen, g.name(g.toMove)+"'s turn", g.smallFont, g.mX/20, g.mY/20, color.White)
+
+		// Spectators can't move, remind them
+		if g.player == 0 {
+			text.Draw(screen, "Watching, Esc leaves", g.tinyFont, g.mX-170, g.mY/20, color.RGBA{160, 160, 160, 255})
+		}
+		if g.local != nil {
+			text.Draw(screen, "Local, Esc leaves", g.tinyFont, g.mX-150, g.mY/20, color.RGBA{160, 160, 160, 255})
+		}
+		g.drawPuzzleGoal(screen)
+
+	}
+}
+
+// drawBoard draws the grid and everything on it, for the game and for
+// going back over it
+func (g *Game) drawBoard(screen *ebiten.Image) {
+	g.renderer().under(g, screen)
+
+	if g.board.Layers > 1 {
+		g.drawCube(screen)
+	} else {
 		// Draw grid lines
-		ebitenutil.DrawLine(screen, float64(g.offset+g.cellSize), float64(g.offset), float64(g.offset+g.cellSize), float64(g.offset+3*g.cellSize), color.White)
-		ebitenutil.DrawLine(screen, float64(g.offset+2*g.cellSize), float64(g.offset), float64(g.offset+2*g.cellSize), float64(g.offset+3*g.cellSize), color.White)
-		ebitenutil.DrawLine(screen, float64(g.offset), float64(g.offset+g.cellSize), float64(g.offset+3*g.cellSize), float64(g.offset+g.cellSize), color.White)
-		ebitenutil.DrawLine(screen, float64(g.offset), float64(g.offset+2*g.cellSize), float64(g.offset+3*g.cellSize), float64(g.offset+2*g.cellSize), color.White)
+		width := g.board.Cols * g.cellSize
+		height := g.board.Rows * g.cellSize
+		for col := 1; col < g.board.Cols; col++ {
+			x := float64(g.offset + col*g.cellSize)
+			ebitenutil.DrawLine(screen, x, float64(g.offset), x, float64(g.offset+height), color.White)
+		}
+		for row := 1; row < g.board.Rows; row++ {
+			y := float64(g.offset + row*g.cellSize)
+			ebitenutil.DrawLine(screen, float64(g.offset), y, float64(g.offset+width), y, color.White)
+		}
 
 		// Draw X/O
-		for row := 0; row < 3; row++ {
-			for col := 0; col < 3; col++ {
+		for row := 0; row < g.board.Rows; row++ {
+			for col := 0; col < g.board.Cols; col++ {
 				x := float64(g.offset + col*g.cellSize)
-				y := float64(g.offset + row*g.cellSize)
+				y := g.dropY(row, col, float64(g.offset + row*g.cellSize))
 				op := &ebiten.DrawImageOptions{}
-			
-				switch g.board[row][col] {
+		
+				if g.renderer().mark(g, screen, row, col, x, y) {
+					continue
+				}
+				switch v := g.board.At(row, col); v {
+				case -1:
+					// blocked in house rules, nobody can go here
+					ebitenutil.DrawRect(screen, x+1, y+1, float64(g.cellSize-2), float64(g.cellSize-2), color.RGBA{90, 90, 90, 255})
 				case 1:
 					scaleX := float64(g.cellSize) / float64(g.imageX.Bounds().Dx())
 					scaleY := float64(g.cellSize) / float64(g.imageX.Bounds().Dy())
@@ -338,37 +667,124 @@ func (g *Game) Draw(screen *ebiten.Image) {
 					op.GeoM.Scale(scaleX, scaleY)
 					op.GeoM.Translate(x, y)
 					screen.DrawImage(g.imageO, op)
+				default:
+					if v > 2 {
+						g.drawPiece(screen, v, x, y)
+					}
 				}
 			}
 		}
-	
-		// Draws line through winner
-		if g.winner != "" && g.winner != "CAT" {
-			for i := 0; i <= 5; i++ {
-				ebitenutil.DrawLine(
-					screen,
-					g.winStartX+float64(i),
-					g.winStartY,
-					g.winEndX+float64(i),
-					g.winEndY,
-					color.RGBA{0, 255, 0, 255}, //green
-				)
-			}
-		}
+		g.renderer().over(g, screen)
+		g.drawHints(screen)
+	}
+}
 
-		// Writes winner
-		if g.winner != "" {
-			text.Draw(screen, g.winner+" Wins! Press 'R' to play again!", g.smallFont, g.mX/20, g.mY/20, color.White)
-			return
+// all boards are drawn this many pixels across, the cells shrink to fit
+const boardPixels = 450
+
+// setRules switches to the board of the room we're in
+func (g *Game) setRules(rules game.Settings) {
+	g.rules = rules
+	g.cellSize = boardPixels / max(rules.Rows, rules.Cols)
+	if rules.Layers > 1 {
+		// two layers side by side, in as many rows as it takes, and the
+		// rows have to fit above the chat
+		stacks := (rules.Layers + 1) / 2
+		wide := (boardPixels - cubeGap) / (2 * rules.Cols)
+		high := (chatTop - cubeChatGap - g.offset - (stacks-1)*cubeGap) / (stacks * rules.Rows)
+		g.cellSize = min(wide, high)
+	}
+}
+
+// resetBoard clears everything about the last game
+func (g *Game) resetBoard() {
+	g.board = g.rules.NewBoard()
+	g.turn = 1
+	g.toMove = 1
+	g.winner = ""
+	g.team = "X"
+	g.current = nil
+	g.spookyFrom = nil
+	g.hint = nil
+	g.moves = nil
+}
+
+// applyUpdate shows the game the way the server (or the local game) says
+// it is now
+func (g *Game) applyUpdate(update game.Update) {
+	g.setRules(update.Rules)
+	g.startDrop(g.board, update.Board)
+	g.board = update.Board
+	g.turn = update.Turn
+	g.toMove = update.ToMove
+	g.winner = update.Winner
+	g.player = update.Player
+	g.names = update.Names
+	g.bots = update.Bots
+	g.noHints = update.NoHints
+	g.hint = nil // it was for the last position
+	g.moves = update.Moves
+	if g.state == StateReview {
+		// the review keeps showing its own board until it's closed, unless
+		// a new game started
+		g.reviewBoard = update.Board
+		if update.Winner == "" {
+			g.state = StatePlaying
 		}
+	}
+	if current, err := update.Rules.Load(update.State); err == nil {
+		g.current = current
+	}
+	g.checkWin()
+}
 
-		// Writes out turns
-		if g.turn % 2 != 0 {
-			text.Draw(screen, "Player 1's turn", g.smallFont, g.mX/20, g.mY/20, color.White)
-		} else {
-			text.Draw(screen, "Player 2's turn", g.smallFont, g.mX/20, g.mY/20, color.White)
+// presets are the boards the menu can pick, the built in ones and then
+// the server's house rules
+func (g *Game) presets() []game.Settings {
+	list := append([]game.Settings{}, game.Presets...)
+	for _, rules := range g.customs {
+		list = append(list, rules.Settings())
+	}
+	return list
+}
+
+// name returns the account name of whoever sits in a seat, or "Player N"
+// while the seat is still empty
+func (g *Game) name(player int) string {
+	if player >= 1 && player <= len(g.names) && g.names[player-1] != "" {
+		if player <= len(g.bots) && g.bots[player-1] {
+			return g.names[player-1] + " (bot)"
 		}
+		return g.names[player-1]
+	}
+	return fmt.Sprintf("Player %d", player)
+}
 
+// winnerName turns the server's "Player N" into the winner's account name
+func (g *Game) winnerName() string {
+	var player int
+	if _, err := fmt.Sscanf(g.winner, "Player %d", &player); err == nil {
+		return g.name(player)
+	}
+	return g.winner
+}
+
+// send wraps v up in an envelope and sends it to the server
+func (g *Game) send(typ string, v any) {
+	if g.server == nil {
+		g.notice = "Not connected to the server"
+		return
+	}
+	if err := g.server.Send(typ, v); err != nil {
+		fmt.Println("Send error:", err)
+	}
+}
+
+// move plays a move in the game we're in. Errors go in the chat panel like
+// the server's do.
+func (g *Game) move(in game.Input) {
+	if err := g.backend().SubmitMove(in); err != nil {
+		g.addChat(game.Message{Text: err.Error()})
 	}
 }
 
@@ -390,49 +806,26 @@ func main() {
 	//conn, err := d.DialContext(ctx, "tcp", "100.118.145.55:8080") // ubuntu vm
 	//conn, err := d.DialContext(ctx, "tcp", "100.108.153.55:8080") // virtual box ubuntu vm
 
+	g := NewGame()
+	g.state = StateLogin
+
 	if err != nil {
+		// no server, but the window still opens for Local games
 		log.Println("Dial error:", err)
-	}
-	defer conn.Close()
-
-	decoder := json.NewDecoder(conn)
-	var init struct{ Player int }
-
-	// player number is the same as saying player id
-	if err := decoder.Decode(&init); err != nil {
-			fmt.Println("Failed to read player number", err)
-			return
+		g.authErr = "Can't reach the server, Local still works"
+	} else {
+		defer conn.Close()
+		g.server = newNetworkBackend(conn)
 	}
 
-	fmt.Println("You are Player: ", init.Player)
-
-	g := NewGame()
-	g.player = init.Player
-	g.conn = conn
-
 	g.imageX, _, _ = ebitenutil.NewImageFromFile("X.png")
 	g.imageO, _, _ = ebitenutil.NewImageFromFile("O.png")
 
 	g.titleFont, err = loadFont("RasterForgeRegular-JpBgm.ttf", 48)
 	g.smallFont, err = loadFont("RasterForgeRegular-JpBgm.ttf", 24)
+	g.tinyFont, err = loadFont("RasterForgeRegular-JpBgm.ttf", 16)
 
-	// go routine that constantly updates the client
-	go func() {
-		decoder := json.NewDecoder(conn)
-
-		for {
-			var update Update
-			if err := decoder.Decode(&update); err != nil {
-				fmt.Println("Decode error:", err)
-				return
-			}
-			// update the game state
-			g.board = update.Board
-			g.turn = update.Turn
-			g.winner = update.Winner
-			g.player = update.Player
-		}
-	}()
+	g.resumeSession()
 
 	ebiten.SetWindowSize(g.mX, g.mY)
 	ebiten.SetWindowTitle("Tic Tac Toe - Go")
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+
+	"tictactoe/game"
+)
+
+// how many tournaments fit in the list at the top of the screen
+const tournamentRows = 6
+
+func (g *Game) updateTournaments() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.notice = ""
+		g.state = StateMenu
+		return
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyUp) && g.selected > 0 {
+		g.selected--
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyDown) && g.selected < len(g.tournaments)-1 {
+		g.selected++
+	}
+
+	// N and K make a new tournament on the board picked in the menu, they
+	// don't need one selected
+	if inpututil.IsKeyJustPressed(ebiten.KeyN) {
+		g.send(game.TypeCreateTournament, game.TournamentRequest{Format: game.FormatRoundRobin, Rules: g.presets()[g.preset]})
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyK) {
+		g.send(game.TypeCreateTournament, game.TournamentRequest{Format: game.FormatElimination, Rules: g.presets()[g.preset]})
+	}
+
+	t := g.selectedTournament()
+	if t == nil {
+		return
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyJ) {
+		g.send(game.TypeJoinTournament, game.TournamentRequest{ID: t.ID})
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyS) {
+		g.send(game.TypeStartTournament, game.TournamentRequest{ID: t.ID})
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyW) {
+		if room := g.runningMatch(t); room != 0 {
+			g.resetBoard()
+			g.notice = ""
+			g.send(game.TypeJoin, game.Join{Room: room, Watch: true})
+			g.state = StatePlaying
+		} else {
+			g.notice = "There's no game to watch right now"
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		if room := g.myMatch(t); room != 0 {
+			g.resetBoard()
+			g.notice = ""
+			g.match = nil
+			g.send(game.TypeJoin, game.Join{Room: room})
+			g.state = StatePlaying
+		} else {
+			g.notice = "You don't have a game to play in this one"
+		}
+	}
+}
+
+func (g *Game) selectedTournament() *game.TournamentInfo {
+	if g.selected >= len(g.tournaments) {
+		g.selected = len(g.tournaments) - 1
+	}
+	if g.selected < 0 {
+		g.selected = 0
+		return nil
+	}
+	return &g.tournaments[g.selected]
+}
+
+// myMatch finds the room of our unplayed game in the current round, 0 if
+// we don't have one
+func (g *Game) myMatch(t *game.TournamentInfo) int {
+	for _, p := range t.Pairings {
+		if p.Room != 0 && (p.Player1 == g.username || p.Player2 == g.username) {
+			return p.Room
+		}
+	}
+	return 0
+}
+
+// runningMatch finds a game of the current round that's still being played
+// so we can watch it, 0 if there isn't one
+func (g *Game) runningMatch(t *game.TournamentInfo) int {
+	for _, p := range t.Pairings {
+		if p.Room != 0 {
+			return p.Room
+		}
+	}
+	return 0
+}
+
+func formatName(format string) string {
+	if format == game.FormatElimination {
+		return "knockout"
+	}
+	return "round robin"
+}
+
+func (g *Game) drawTournaments(screen *ebiten.Image) {
+	screen.Fill(color.RGBA{30, 30, 30, 255})
+
+	gray := color.RGBA{160, 160, 160, 255}
+	gold := color.RGBA{255, 215, 0, 255}
+
+	text.Draw(screen, "Tournaments", g.titleFont, 30, 60, color.White)
+
+	if len(g.tournaments) == 0 {
+		text.Draw(screen, "No tournaments yet, press N or K to make one", g.tinyFont, 30, 100, gray)
+	}
+
+	// scroll the list so the selected one is always showing
+	first := 0
+	if g.selected >= tournamentRows {
+		first = g.selected - tournamentRows + 1
+	}
+	for i := first; i < len(g.tournaments) && i < first+tournamentRows; i++ {
+		t := g.tournaments[i]
+		board := fmt.Sprintf("%dx%d k=%d", t.Rules.Rows, t.Rules.Cols, t.Rules.K)
+		if t.Rules.Variant != "" {
+			board = t.Rules.Variant
+		} else if t.Rules.Layers > 1 {
+			board = fmt.Sprintf("%dx%dx%d", t.Rules.Layers, t.Rules.Rows, t.Rules.Cols)
+		}
+		line := fmt.Sprintf("%s  %s  %s  %s  %d players", t.Name, formatName(t.Format), board, t.Status, len(t.Players))
+
+		clr := color.Color(color.White)
+		if i == g.selected {
+			line = "> " + line
+			clr = gold
+		}
+		text.Draw(screen, line, g.tinyFont, 30, 100+(i-first)*22, clr)
+	}
+
+	y := 100 + tournamentRows*22 + 20
+	if t := g.selectedTournament(); t != nil {
+		y = g.drawTournament(screen, t, y)
+	}
+
+	if g.notice != "" {
+		text.Draw(screen, g.notice, g.tinyFont, 30, g.mY-55, color.RGBA{255, 90, 90, 255})
+	}
+	text.Draw(screen, "Up/Down pick  N new round robin  K new knockout", g.tinyFont, 30, g.mY-32, gray)
+	text.Draw(screen, "J join  S start  Enter play my game  W watch  Esc back", g.tinyFont, 30, g.mY-12, gray)
+}
+
+// drawTournament shows the current round and the standings of one
+// tournament starting at y, and returns where it stopped
+func (g *Game) drawTournament(screen *ebiten.Image, t *game.TournamentInfo, y int) int {
+	gray := color.RGBA{160, 160, 160, 255}
+
+	switch t.Status {
+	case "registering":
+		text.Draw(screen, fmt.Sprintf("Waiting for %s to start it. Players: %v", t.Creator, t.Players), g.tinyFont, 30, y, color.White)
+		return y + 22
+	case "finished":
+		text.Draw(screen, "Winner: "+t.Winner, g.smallFont, 30, y, color.RGBA{255, 215, 0, 255})
+	default:
+		text.Draw(screen, fmt.Sprintf("Round %d", t.Round), g.smallFont, 30, y, color.White)
+	}
+	y += 26
+
+	for _, p := range t.Pairings {
+		line := p.Player1 + " vs " + p.Player2
+		if p.Player2 == "" {
+			line = p.Player1
+		}
+		result := p.Result
+		if result == "" {
+			result = "playing"
+		}
+		text.Draw(screen, line, g.tinyFont, 30, y, color.White)
+		text.Draw(screen, result, g.tinyFont, 330, y, gray)
+		y += 20
+	}
+	y += 12
+
+	// standings table
+	text.Draw(screen, "#", g.tinyFont, 30, y, gray)
+	text.Draw(screen, "Name", g.tinyFont, 60, y, gray)
+	text.Draw(screen, "Pts", g.tinyFont, 250, y, gray)
+	if t.Format == game.FormatRoundRobin {
+		text.Draw(screen, "SB", g.tinyFont, 310, y, gray)
+	}
+	text.Draw(screen, "W/D/L", g.tinyFont, 370, y, gray)
+	y += 20
+
+	for _, s := range t.Standings {
+		if y > g.mY-70 {
+			break
+		}
+		clr := color.Color(color.White)
+		if s.Username == g.username {
+			clr = color.RGBA{255, 215, 0, 255}
+		}
+		text.Draw(screen, fmt.Sprint(s.Rank), g.tinyFont, 30, y, clr)
+		text.Draw(screen, s.Username, g.tinyFont, 60, y, clr)
+		text.Draw(screen, fmt.Sprint(s.Points), g.tinyFont, 250, y, clr)
+		if t.Format == game.FormatRoundRobin {
+			text.Draw(screen, fmt.Sprint(s.TieBreak), g.tinyFont, 310, y, clr)
+		}
+		text.Draw(screen, fmt.Sprintf("%d/%d/%d", s.Wins, s.Draws, s.Losses), g.tinyFont, 370, y, clr)
+		text.Draw(screen, s.Status, g.tinyFont, 450, y, gray)
+		y += 20
+	}
+	return y
+}
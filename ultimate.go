@@ -0,0 +1,85 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+
+	"tictactoe/game"
+)
+
+// ultimateRenderer draws the small boards of ultimate
+type ultimateRenderer struct{ plainRenderer }
+
+// ultimateState is the state of the ultimate game we're in, nil until the
+// server sends it
+func (g *Game) ultimateState() *game.UltimateState {
+	if u, ok := g.current.(*game.UltimateGame); ok {
+		return &u.Ultimate
+	}
+	return nil
+}
+
+// click sends a click on cell row, col of the 9x9 board as a move in the
+// small board it's in. We don't draw it ourselves, only the server knows
+// for sure if that board is allowed.
+func (ultimateRenderer) click(g *Game, pos game.Pos) bool {
+	small := pos.Row/3*3 + pos.Col/3
+	g.move(game.Input{Player: g.player, Board: small, Pos: game.Pos{Row: pos.Row % 3, Col: pos.Col % 3}})
+	return true
+}
+
+// under lights up the small boards the next move can go in. It's drawn
+// before the grid so the lines stay on top.
+func (ultimateRenderer) under(g *Game, screen *ebiten.Image) {
+	u := g.ultimateState()
+	if u == nil || g.winner != "" {
+		return
+	}
+	size := float64(3 * g.cellSize)
+	for small := 0; small < 9; small++ {
+		if u.Boards[small] != 0 || (u.Next != -1 && u.Next != small) {
+			continue
+		}
+		x := float64(g.offset) + float64(small%3)*size
+		y := float64(g.offset) + float64(small/3)*size
+		ebitenutil.DrawRect(screen, x, y, size, size, color.RGBA{60, 60, 20, 255})
+	}
+}
+
+// over draws the thick lines between the small boards and a big X or O
+// over every small board somebody has taken
+func (ultimateRenderer) over(g *Game, screen *ebiten.Image) {
+	u := g.ultimateState()
+	if u == nil {
+		return
+	}
+	size := 3 * g.cellSize
+	total := float64(3 * size)
+	for i := 1; i < 3; i++ {
+		at := float64(g.offset + i*size)
+		ebitenutil.DrawRect(screen, at-2, float64(g.offset), 4, total, color.White)
+		ebitenutil.DrawRect(screen, float64(g.offset), at-2, total, 4, color.White)
+	}
+
+	for small, owner := range u.Boards {
+		x := float64(g.offset + small%3*size)
+		y := float64(g.offset + small/3*size)
+		var img *ebiten.Image
+		switch owner {
+		case 1:
+			img = g.imageX
+		case 2:
+			img = g.imageO
+		case -1:
+			// drawn, just grey it out
+			ebitenutil.DrawRect(screen, x, y, float64(size), float64(size), color.RGBA{0, 0, 0, 140})
+			continue
+		default:
+			continue
+		}
+		ebitenutil.DrawRect(screen, x, y, float64(size), float64(size), color.RGBA{0, 0, 0, 170})
+		g.drawImage(screen, img, x, y, float64(size))
+	}
+}